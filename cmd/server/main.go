@@ -1,19 +1,37 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	_ "github.com/surfe/mock-api/docs"
+	"github.com/surfe/mock-api/internal/clock"
 	"github.com/surfe/mock-api/internal/data"
 	"github.com/surfe/mock-api/internal/database"
+	"github.com/surfe/mock-api/internal/events"
+	"github.com/surfe/mock-api/internal/fault"
+	"github.com/surfe/mock-api/internal/fixtures"
 	"github.com/surfe/mock-api/internal/handlers"
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/problem"
+	"github.com/surfe/mock-api/internal/provider"
+	"github.com/surfe/mock-api/internal/rng"
+	"github.com/surfe/mock-api/internal/scenario"
+	"github.com/surfe/mock-api/internal/webhook"
 	"github.com/surfe/mock-api/internal/worker"
 )
 
@@ -33,53 +51,247 @@ import (
 // @BasePath  /
 
 func main() {
+	fixturesPath := flag.String("fixtures", os.Getenv("FIXTURES"), "JSON/YAML fixture file or directory replacing the built-in contacts, third-party info, providers, enrichment data and static enrichments (env FIXTURES)")
+	legacyErrors := flag.Bool("legacy-errors", os.Getenv("LEGACY_ERRORS") == "true", "Write errors as {error, code, message} instead of application/problem+json (env LEGACY_ERRORS=true)")
+	flag.Parse()
+
+	// Everything in the enrichment lifecycle runs on a virtual clock, which tests can speed up
+	// or advance through /admin/clock; it runs in real time unless CLOCK_SPEED says otherwise
+	speed := 1.0
+	if raw := os.Getenv("CLOCK_SPEED"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed < 0 || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+			log.Fatalf("Invalid CLOCK_SPEED %q: must be a finite number >= 0", raw)
+		}
+		speed = parsed
+	}
+	clk := clock.NewVirtual(time.Now(), speed)
+
 	// Initialize in-memory SQLite database (fresh on each restart)
-	db, err := database.New(":memory:")
+	db, err := database.New(":memory:", clk)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
 
+	// Initialize mock data for contacts and third-party info
+	mockData := data.NewMockData()
+
+	// Replace the built-in data with fixtures; sections they leave out keep the built-in data
+	if *fixturesPath != "" {
+		set, err := fixtures.Load(*fixturesPath)
+		if err != nil {
+			log.Fatalf("Failed to load fixtures: %v", err)
+		}
+		set.Apply(mockData, db)
+		log.Printf("Loaded fixtures from %s", *fixturesPath)
+	}
+
+	// Contacts live in the database, seeded from the built-in or fixture contacts
+	db.SetSeedContacts(mockData.GetAllContacts())
+	if err := db.SeedContacts(); err != nil {
+		log.Fatalf("Failed to seed contacts: %v", err)
+	}
+
 	// Seed static enrichments (always available after restart)
 	if err := db.SeedStaticEnrichments(); err != nil {
 		log.Fatalf("Failed to seed static enrichments: %v", err)
 	}
 
-	// Initialize mock data for contacts and third-party info
-	mockData := data.NewMockData()
+	// Initialize the in-process pub/sub for enrichment progress events
+	broker := events.NewBroker(events.DefaultHistorySize, clk)
 
-	// Initialize handlers
-	h := handlers.NewHandler(mockData, db)
+	// A fixed seed makes every run of the server draw the same enrichment seeds, delays and outcomes;
+	// handlers and worker get separate streams so neither depends on how much the other draws
+	handlerConfig := handlers.DefaultConfig()
+	workerConfig := worker.DefaultConfig()
+	handlerConfig.Clock = clk
+	workerConfig.Clock = clk
+	if raw := os.Getenv("SEED"); raw != "" {
+		seed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid SEED %q: %v", raw, err)
+		}
+		handlerConfig.Rand = rng.Derive(seed, "handlers")
+		workerConfig.Rand = rng.Derive(seed, "worker")
+		handlerConfig.Faults = fault.New(rng.Derive(seed, "faults"))
+		log.Printf("Using seed %d for all randomness", seed)
+	}
+
+	// Initialize webhook delivery for enrichments started with a callbackUrl
+	webhookConfig := webhook.DefaultConfig()
+	webhookConfig.Clock = clk
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		webhookConfig.Secret = secret
+	}
+	webhooks := webhook.New(db, mockData, webhookConfig)
+
+	// Load per-provider latency, success rates, cost and coverage, replacing the default providers
+	if path := os.Getenv("PROVIDER_CONFIG"); path != "" {
+		configs, err := provider.LoadConfigs(path)
+		if err != nil {
+			log.Fatalf("Failed to load provider config: %v", err)
+		}
+		mockData.SetProviderConfigs(configs)
+		log.Printf("Loaded %d providers from %s", len(configs), path)
+	}
+
+	// Load the fault injection rules applied from startup; they can be changed through /admin/faults
+	if path := os.Getenv("FAULT_CONFIG"); path != "" {
+		rules, err := fault.LoadRules(path)
+		if err == nil {
+			err = handlerConfig.Faults.SetRules(rules)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load fault config: %v", err)
+		}
+		log.Printf("Loaded %d fault rules from %s", len(rules), path)
+	}
+
+	// Load the scenarios enrichments can replay by name instead of rolling dice
+	if dir := os.Getenv("SCENARIO_DIR"); dir != "" {
+		scenarios, err := scenario.LoadDir(dir)
+		if err != nil {
+			log.Fatalf("Failed to load scenarios: %v", err)
+		}
+		mockData.SetScenarios(scenarios)
+		log.Printf("Loaded %d scenarios from %s", len(scenarios), dir)
+	}
+
+	// Every provider takes a random time and finds the value with its configured probability
+	providers := provider.NewRandomProviders(mockData)
 
 	// Start background worker for enrichment processing
-	w := worker.New(db, mockData, worker.DefaultConfig())
+	w := worker.New(db, mockData, broker, webhooks, providers, workerConfig)
 	w.Start()
 
+	// Initialize handlers
+	if ttl := os.Getenv("IDEMPOTENCY_KEY_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatalf("Invalid IDEMPOTENCY_KEY_TTL %q: %v", ttl, err)
+		}
+		handlerConfig.IdempotencyKeyTTL = parsed
+	}
+	// Resets and snapshot restores stop the worker while they replace the state
+	handlerConfig.Worker = w
+	// Deleting a contact fails its running enrichments, which still get delivered to their callback URL
+	handlerConfig.Webhooks = webhooks
+	// Contact edits can be made to carry the ETag they are based on, so none silently overwrites another
+	handlerConfig.RequireIfMatch = os.Getenv("REQUIRE_IF_MATCH") == "true"
+	h := handlers.NewHandler(mockData, db, broker, handlerConfig)
+
 	// Setup routes
 	mux := http.NewServeMux()
 
 	// API endpoints
-	mux.HandleFunc("/contacts", h.GetContacts)
+	mux.HandleFunc("/contacts", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetContacts(w, r)
+		case http.MethodPost:
+			h.CreateContact(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	})
 	mux.HandleFunc("/contact/", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			h.GetContact(w, r)
 		case http.MethodPut:
 			h.UpdateContact(w, r)
+		case http.MethodDelete:
+			h.DeleteContact(w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			methodNotAllowed(w, r)
 		}
 	})
 	mux.HandleFunc("/enrichment/start", h.StartEnrichment)
-	mux.HandleFunc("/enrichment/", h.GetEnrichment)
+	mux.HandleFunc("/enrichment/bulk", h.BulkStartEnrichment)
+	mux.HandleFunc("/enrichment/bulk/", h.GetBulkEnrichment)
+	mux.HandleFunc("/enrichments", h.ListEnrichments)
+	mux.HandleFunc("/enrichment/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/retry") {
+			h.RetryEnrichment(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/events") {
+			h.StreamEnrichmentEvents(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/deliveries") {
+			h.GetWebhookDeliveries(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			h.GetEnrichment(w, r)
+		case http.MethodDelete:
+			h.CancelEnrichment(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	})
+	mux.HandleFunc("/ws", h.EnrichmentWebSocket)
+	mux.HandleFunc("/providers", h.GetProviders)
+	mux.HandleFunc("/scenarios", h.ListScenarios)
 	mux.HandleFunc("/thirdparty/", h.GetThirdPartyInfo)
+	mux.HandleFunc("/admin/clock", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetClock(w, r)
+		case http.MethodPut:
+			h.SetClockSpeed(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	})
+	mux.HandleFunc("/admin/clock/advance", h.AdvanceClock)
+	mux.HandleFunc("/admin/faults", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetFaults(w, r)
+		case http.MethodPut:
+			h.SetFaults(w, r)
+		case http.MethodDelete:
+			h.ClearFaults(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	})
+	mux.HandleFunc("/admin/reset", h.ResetState)
+	mux.HandleFunc("/admin/snapshots", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.ListSnapshots(w, r)
+		case http.MethodPost:
+			h.CreateSnapshot(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	})
+	mux.HandleFunc("/admin/snapshots/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/restore") {
+			h.RestoreSnapshot(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodDelete:
+			h.DeleteSnapshot(w, r)
+		default:
+			methodNotAllowed(w, r)
+		}
+	})
 	mux.HandleFunc("/health", h.HealthCheck)
 
 	// Swagger documentation
 	mux.HandleFunc("/docs/", httpSwagger.WrapHandler)
 
-	// Apply middleware chain: logging -> CORS -> handler
-	handler := loggingMiddleware(corsMiddleware(mux))
+	// Apply middleware chain: trace ID -> logging -> CORS -> faults -> handler
+	handler := problem.Middleware(*legacyErrors, loggingMiddleware(corsMiddleware(faultMiddleware(handlerConfig.Faults, mux))))
 
 	// Get port from environment or default to 8080
 	port := os.Getenv("PORT")
@@ -100,6 +312,12 @@ func main() {
 	log.Printf("Starting server on :%s", port)
 	log.Printf("Swagger docs available at http://localhost:%s/docs/", port)
 	log.Println("Using in-memory database (data resets on restart, seed data always available)")
+	if speed != 1 {
+		log.Printf("Virtual clock running at %gx speed", speed)
+	}
+	if *legacyErrors {
+		log.Println("Writing errors in the legacy {error, code, message} shape")
+	}
 
 	if err := http.ListenAndServe(":"+port, handler); err != nil {
 		log.Fatal(err)
@@ -117,6 +335,23 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush lets streaming handlers (Server-Sent Events) flush through the wrapper
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the WebSocket handler take over the connection through the wrapper
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	rw.statusCode = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
 // loggingMiddleware logs all incoming HTTP requests
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -130,7 +365,7 @@ func loggingMiddleware(next http.Handler) http.Handler {
 
 		// Log request details
 		duration := time.Since(start)
-		log.Printf("%s %s %d %v", r.Method, r.URL.Path, wrapped.statusCode, duration)
+		log.Printf("%s %s %d %v trace=%s", r.Method, r.URL.Path, wrapped.statusCode, duration, problem.TraceID(r))
 	})
 }
 
@@ -139,7 +374,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID, Idempotency-Key, X-Seed, X-Mock-Fault, traceparent, If-Match, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "Idempotent-Replayed, X-Mock-Fault-Injected, X-Total-Count, X-Next-Cursor, X-Trace-Id, ETag")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -149,3 +385,99 @@ func corsMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// faultMiddleware injects the faults picked by the injector: latency first, then an error status,
+// a timeout, a dropped connection or a truncated body. Admin and docs routes are never affected
+// so faults can always be turned off again.
+func faultMiddleware(faults *fault.Injector, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/admin/") || strings.HasPrefix(r.URL.Path, "/docs/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		picked, err := faults.Pick(r)
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "", err.Error(), nil)
+			return
+		}
+		if len(picked) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		latency := 0
+		var inject *models.FaultRule
+		names := make([]string, 0, len(picked))
+		for i, rule := range picked {
+			if rule.Type == models.FaultTypeLatency {
+				latency += rule.LatencyMs
+			} else if inject == nil {
+				inject = &picked[i]
+			} else {
+				continue
+			}
+			names = append(names, string(rule.Type))
+		}
+		log.Printf("Injecting %s into %s %s", strings.Join(names, ", "), r.Method, r.URL.Path)
+		w.Header().Set("X-Mock-Fault-Injected", strings.Join(names, ", "))
+
+		if latency > 0 {
+			timer := time.NewTimer(time.Duration(latency) * time.Millisecond)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+
+		if inject == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch inject.Type {
+		case models.FaultTypeError:
+			status := inject.Status
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			problem.Write(w, r, status, problem.TypeInjectedFault, "injected fault", nil)
+		case models.FaultTypeTimeout:
+			// Never answer; the client gives up first
+			<-r.Context().Done()
+		case models.FaultTypeDrop:
+			// Aborting the handler closes the connection without writing a response
+			panic(http.ErrAbortHandler)
+		case models.FaultTypeTruncate:
+			// Streams never finish, so there is no body to cut in half
+			if r.Header.Get("Upgrade") != "" || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			buffered := &bufferedWriter{header: w.Header(), statusCode: http.StatusOK}
+			next.ServeHTTP(buffered, r)
+			w.Header().Del("Content-Length")
+			w.WriteHeader(buffered.statusCode)
+			body := buffered.body.Bytes()
+			w.Write(body[:len(body)/2])
+		}
+	})
+}
+
+// methodNotAllowed writes the error for a method a route does not handle
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, http.StatusMethodNotAllowed, "", "method not allowed", nil)
+}
+
+// bufferedWriter holds back a response so it can be truncated
+type bufferedWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (bw *bufferedWriter) Header() http.Header         { return bw.header }
+func (bw *bufferedWriter) WriteHeader(code int)        { bw.statusCode = code }
+func (bw *bufferedWriter) Write(b []byte) (int, error) { return bw.body.Write(b) }
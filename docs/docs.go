@@ -24,45 +24,33 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/contact/{id}": {
+        "/admin/clock": {
             "get": {
-                "description": "Returns the basic information around the contact based on their ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the current virtual time, its speed and when each pending timer (worker poll, provider answer) is due",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "contacts"
-                ],
-                "summary": "Get contact by ID",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Contact ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "admin"
                 ],
+                "summary": "Get the virtual clock",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Contact"
+                            "$ref": "#/definitions/models.ClockState"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Updates the phone and/or email of a contact by ID",
+                "description": "Sets how many virtual seconds pass per real second for the whole enrichment lifecycle. 10 runs everything ten times faster, 0 pauses the clock so it only moves through /admin/clock/advance",
                 "consumes": [
                     "application/json"
                 ],
@@ -70,24 +58,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "contacts"
+                    "admin"
                 ],
-                "summary": "Update contact phone and/or email",
+                "summary": "Set the virtual clock speed",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Contact ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Update request",
+                        "description": "Clock speed",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateContactRequest"
+                            "$ref": "#/definitions/models.ClockSpeedRequest"
                         }
                     }
                 ],
@@ -95,27 +76,27 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Contact"
+                            "$ref": "#/definitions/models.ClockState"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             }
         },
-        "/contacts": {
-            "get": {
-                "description": "Returns all available contacts from the database",
+        "/admin/clock/advance": {
+            "post": {
+                "description": "Moves the virtual clock forward by a duration, or to the next pending timer with next set, firing every timer that becomes due. Timers scheduled in response, such as the next provider lookup, start from the new time, so step with next to stop at each provider answer",
                 "consumes": [
                     "application/json"
                 ],
@@ -123,51 +104,66 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "contacts"
+                    "admin"
+                ],
+                "summary": "Advance the virtual clock",
+                "parameters": [
+                    {
+                        "description": "How far to advance",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ClockAdvanceRequest"
+                        }
+                    }
                 ],
-                "summary": "Get all contacts",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Contact"
-                            }
+                            "$ref": "#/definitions/models.ClockState"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             }
         },
-        "/contacts": {
+        "/admin/faults": {
             "get": {
-                "description": "Returns all available contacts from the database",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the rules that inject latency, error statuses, timeouts, dropped connections or truncated bodies into matching requests",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "contacts"
+                    "admin"
                 ],
-                "summary": "Get all contacts",
+                "summary": "Get the fault injection rules",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Contact"
+                                "$ref": "#/definitions/models.FaultRule"
                             }
                         }
                     }
                 }
-            }
-        },
-        "/contacts": {
-            "get": {
-                "description": "Returns all available contacts from the database",
+            },
+            "put": {
+                "description": "Replaces every rule. A rule applies to requests matching its method and path pattern (e.g. \"/enrichment/*\") with its probability; each matching latency rule adds its delay and the first other matching fault is injected. Admin and docs routes are never affected. A single request can force faults with the X-Mock-Fault header instead, e.g. \"latency=2000,error=503\"",
                 "consumes": [
                     "application/json"
                 ],
@@ -175,51 +171,98 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "contacts"
+                    "admin"
+                ],
+                "summary": "Replace the fault injection rules",
+                "parameters": [
+                    {
+                        "description": "Fault rules",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FaultRule"
+                            }
+                        }
+                    }
                 ],
-                "summary": "Get all contacts",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Contact"
+                                "$ref": "#/definitions/models.FaultRule"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stops injecting faults; requests sending the X-Mock-Fault header still get theirs",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Remove all fault injection rules",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/admin/reset": {
+            "post": {
+                "description": "Stops the enrichments being processed and the webhook deliveries being retried, deletes every contact, enrichment, batch, provider attempt, webhook delivery and idempotency key, and seeds the built-in or fixture contacts and the static enrichments again. Providers, scenarios, fault rules, the clock and snapshots are kept",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reset to the seed state",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
                     }
                 }
             }
         },
-        "/contacts": {
+        "/admin/snapshots": {
             "get": {
-                "description": "Returns all available contacts from the database",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns every snapshot taken since the server started, sorted by name",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "contacts"
+                    "admin"
                 ],
-                "summary": "Get all contacts",
+                "summary": "List snapshots",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Contact"
+                                "$ref": "#/definitions/models.SnapshotInfo"
                             }
                         }
                     }
                 }
-            }
-        },
-        "/enrichment/start": {
+            },
             "post": {
-                "description": "Starts an enrichment process, taking the userID and additional optional payload",
+                "description": "Copies the current contacts and enrichments, with their batches, provider attempts, webhook deliveries and idempotency keys, under a name. Taking a snapshot under an existing name replaces it. Snapshots live in memory and are lost on restart",
                 "consumes": [
                     "application/json"
                 ],
@@ -227,17 +270,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "enrichment"
+                    "admin"
                 ],
-                "summary": "Start an enrichment",
+                "summary": "Take a snapshot",
                 "parameters": [
                     {
-                        "description": "Enrichment request",
+                        "description": "Snapshot name",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.EnrichmentStartRequest"
+                            "$ref": "#/definitions/models.SnapshotRequest"
                         }
                     }
                 ],
@@ -245,36 +288,68 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.EnrichmentStartResponse"
+                            "$ref": "#/definitions/models.SnapshotInfo"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             }
         },
-        "/enrichment/{enrichmentId}": {
-            "get": {
-                "description": "Returns the status of the enrichment based on the enrichment ID",
-                "consumes": [
-                    "application/json"
+        "/admin/snapshots/{name}": {
+            "delete": {
+                "description": "Forgets a snapshot",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/snapshots/{name}/restore": {
+            "post": {
+                "description": "Stops the enrichments being processed and the webhook deliveries being retried, and replaces the contacts and enrichments with the snapshot's. Enrichments that were in progress when the snapshot was taken start over from their completed jobs",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "enrichment"
+                    "admin"
                 ],
-                "summary": "Get enrichment status",
+                "summary": "Restore a snapshot",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Enrichment ID",
-                        "name": "enrichmentId",
+                        "description": "Snapshot name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
@@ -283,44 +358,78 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Enrichment"
+                            "$ref": "#/definitions/models.SnapshotInfo"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             }
         },
-        "/health": {
+        "/contact/{id}": {
             "get": {
-                "description": "Returns the health status of the API",
+                "description": "Returns the basic information around the contact based on their ID, with its version as the ETag. Sending that ETag in If-None-Match returns 304 while the contact is unchanged",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "contacts"
+                ],
+                "summary": "Get contact by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Contact ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy the client holds",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "$ref": "#/definitions/models.Contact"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the contact, e.g. \"3\""
                             }
                         }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
                     }
                 }
-            }
-        },
-        "/thirdparty/{full_name}": {
-            "get": {
-                "description": "Returns additional information about the user based on their full name",
+            },
+            "put": {
+                "description": "Updates the phone and/or email of a contact by ID, at least one of which is required; an empty string clears it. The phone is kept in the form it was given in, with its E.164 form as phoneE164, and the email with a lowercase domain. Invalid fields are listed in the errors of the 400 response. With If-Match, the update only applies if the contact's ETag still matches, 412 otherwise; the server can be configured to require it (REQUIRE_IF_MATCH=true), answering 428 without it",
                 "consumes": [
                     "application/json"
                 ],
@@ -328,29 +437,840 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "thirdparty"
+                    "contacts"
                 ],
-                "summary": "Get third-party information",
+                "summary": "Update contact phone and/or email",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Full name (URL encoded)",
-                        "name": "full_name",
+                        "description": "Contact ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the contact the update is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Update request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateContactRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ThirdPartyInfo"
+                            "$ref": "#/definitions/models.Contact"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated contact"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a contact by ID. Its pending and in_progress enrichments fail with the error \"contact was deleted\", keeping whatever was found so far, and are delivered to their callback URL. Finished enrichments are kept as they are",
+                "tags": [
+                    "contacts"
+                ],
+                "summary": "Delete a contact",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Contact ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/contacts": {
+            "get": {
+                "description": "Returns the contacts from the database, optionally searched, filtered, sorted and paginated. Contacts are sorted by last then first name by default and ties are broken by ID, so pages stay stable. Without a limit every matching contact is returned. X-Total-Count holds the number of matching contacts across all pages and X-Next-Cursor, set when there are more, is the cursor of the next page",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "contacts"
+                ],
+                "summary": "Get all contacts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive search across name, email, company and job title",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only contacts of this company (case-insensitive)",
+                        "name": "company",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only contacts with (true) or without (false) a phone",
+                        "name": "hasPhone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only contacts with (true) or without (false) an email",
+                        "name": "hasEmail",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field: name (default), firstName, lastName, email, phone, company, jobTitle, createdAt or updatedAt",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned in X-Next-Cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Contact"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, if any"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of contacts matching the filters"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a contact with a server-generated ID. firstName and lastName are required. The phone is kept in the form it was given in, with its E.164 form as phoneE164, and the email with a lowercase domain. Invalid fields are listed in the errors of the 400 response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "contacts"
+                ],
+                "summary": "Create a contact",
+                "parameters": [
+                    {
+                        "description": "Contact",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateContactRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Contact"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrichment/bulk": {
+            "post": {
+                "description": "Starts one enrichment per item and groups them in a batch. Send either items, an array of enrichment start requests, or userIds with shared jobs and callbackUrl. Nothing is started if any item is invalid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "Start many enrichments at once",
+                "parameters": [
+                    {
+                        "description": "Bulk enrichment request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkEnrichmentRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seed from which every entry of userIds derives its own, used when the body has no seed",
+                        "name": "X-Seed",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkEnrichmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrichment/bulk/{batchId}": {
+            "get": {
+                "description": "Returns the aggregate progress of a batch: how many enrichments are in each status, how many phone and email results were found, not found or are still pending, and the IDs of every enrichment in the batch",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "Get bulk enrichment progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch ID",
+                        "name": "batchId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrichment/start": {
+            "post": {
+                "description": "Starts an enrichment process, taking the userID and additional optional payload. Set dedupe to \"existing\" to get back a running enrichment that already covers the requested jobs, or to \"missing\" to only start the jobs no running enrichment covers; deduplicated requests return 200 with deduplicated set. Starting an enrichment with the seed of another one replays the same provider delays and outcomes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "Start an enrichment",
+                "parameters": [
+                    {
+                        "description": "Enrichment request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EnrichmentStartRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the original response when the same request is sent again with this key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seed of the enrichment, used when the body has no seed",
+                        "name": "X-Seed",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EnrichmentStartResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.EnrichmentStartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrichment/{enrichmentId}": {
+            "get": {
+                "description": "Returns the status of the enrichment based on the enrichment ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "Get enrichment status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Enrichment ID",
+                        "name": "enrichmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Enrichment"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels a pending or in-progress enrichment. The provider lookup in flight is interrupted and any result already found is kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "Cancel an enrichment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Enrichment ID",
+                        "name": "enrichmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Enrichment"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrichment/{enrichmentId}/deliveries": {
+            "get": {
+                "description": "Returns every attempt to deliver the enrichment to its callbackUrl, oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Enrichment ID",
+                        "name": "enrichmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrichment/{enrichmentId}/events": {
+            "get": {
+                "description": "Server-Sent Events stream of an enrichment's progress. A snapshot event with the current enrichment is sent first, followed by provider_changed, value_found, job_completed and status_changed events. The stream ends after a terminal status. Reconnect with Last-Event-ID to receive missed events, or a new snapshot if they are no longer kept",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "Stream enrichment progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Enrichment ID",
+                        "name": "enrichmentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received before reconnecting",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrichment/{enrichmentId}/retry": {
+            "post": {
+                "description": "Starts a new enrichment for the jobs of a failed, cancelled or completed enrichment that came back empty. The new enrichment re-uses the original contact info and links back to it through retryOf",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "Retry an enrichment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Enrichment ID",
+                        "name": "enrichmentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.EnrichmentStartResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrichments": {
+            "get": {
+                "description": "Returns a page of enrichments, optionally filtered by user, status, job type and creation date. Use nextCursor from the response as cursor to fetch the next page",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "List enrichments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only enrichments for this user ID",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only enrichments started in this bulk batch",
+                        "name": "batchId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated statuses (pending, in_progress, completed, failed, cancelled)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only enrichments that requested this job type (phone or email)",
+                        "name": "job",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only enrichments created at or after this RFC3339 time",
+                        "name": "createdAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only enrichments created before this RFC3339 time",
+                        "name": "createdBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field: createdAt (default) or updatedAt",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: desc (default) or asc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as nextCursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EnrichmentList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Returns the health status of the API",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/providers": {
+            "get": {
+                "description": "Returns every provider with its configured behaviour: supported job types, credit cost, latency distribution and success rates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Get all providers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProviderConfig"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios": {
+            "get": {
+                "description": "Returns every scenario loaded at startup, which can be referenced by name when starting an enrichment",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "List scenarios",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Scenario"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/thirdparty/{full_name}": {
+            "get": {
+                "description": "Returns additional information about the user based on their full name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thirdparty"
+                ],
+                "summary": "Get third-party information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Full name (URL encoded)",
+                        "name": "full_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seed of the artificial latency",
+                        "name": "X-Seed",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ThirdPartyInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "description": "Upgrades to a WebSocket. Send {\"action\":\"subscribe\",\"enrichmentIds\":[...]} or {\"action\":\"subscribe\",\"userId\":\"...\"} to watch enrichments, \"unsubscribe\" to stop and \"ping\" to get a \"pong\". Every change to a watched enrichment is pushed as {\"type\":\"enrichment\",\"event\":\"...\",\"enrichment\":{...}} with the same payload as GET /enrichment/{id}",
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "Watch many enrichments over one WebSocket",
+                "parameters": [
+                    {
+                        "description": "Client message",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebSocketRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebSocketMessage"
                         }
                     }
                 }
@@ -358,208 +1278,921 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "enrichmentId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "job": {
+                    "type": "string"
+                },
+                "provider": {
+                    "$ref": "#/definitions/models.Provider"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.EnrichmentStatus"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/events.Type"
+                },
+                "userId": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "events.Type": {
+            "type": "string",
+            "enum": [
+                "provider_changed",
+                "value_found",
+                "job_completed",
+                "status_changed"
+            ],
+            "x-enum-varnames": [
+                "TypeProviderChanged",
+                "TypeValueFound",
+                "TypeJobCompleted",
+                "TypeStatusChanged"
+            ]
+        },
+        "models.AttemptOutcome": {
+            "type": "string",
+            "enum": [
+                "in_progress",
+                "found",
+                "not_found",
+                "error",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "AttemptOutcomeInProgress",
+                "AttemptOutcomeFound",
+                "AttemptOutcomeNotFound",
+                "AttemptOutcomeError",
+                "AttemptOutcomeCancelled"
+            ]
+        },
+        "models.BatchJobProgress": {
+            "type": "object",
+            "properties": {
+                "found": {
+                    "type": "integer"
+                },
+                "notFound": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "requested": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BatchStatus": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "done": {
+                    "description": "True once every enrichment reached a final status",
+                    "type": "boolean"
+                },
+                "enrichmentIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "jobs": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.BatchJobProgress"
+                    }
+                },
+                "statusCounts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.BulkEnrichmentRequest": {
+            "type": "object",
+            "properties": {
+                "callbackUrl": {
+                    "description": "Callback shared by every entry of userIds",
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EnrichmentStartRequest"
+                    }
+                },
+                "jobs": {
+                    "description": "Jobs shared by every entry of userIds",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JobType"
+                    }
+                },
+                "providerOrder": {
+                    "description": "ProviderOrder shared by every entry of userIds",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.ProviderOrder"
+                    }
+                },
+                "scenario": {
+                    "description": "Scenario shared by every entry of userIds",
+                    "type": "object"
+                },
+                "seed": {
+                    "description": "Seed from which every entry of userIds derives its own seed",
+                    "type": "integer"
+                },
+                "userIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.BulkEnrichmentResponse": {
+            "type": "object",
+            "properties": {
+                "batchId": {
+                    "type": "string"
+                },
+                "enrichmentIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ClockAdvanceRequest": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "Go duration such as \"5s\" or \"1m30s\"",
+                    "type": "string"
+                },
+                "next": {
+                    "description": "Jump to the next pending timer instead",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ClockSpeedRequest": {
+            "type": "object",
+            "properties": {
+                "speed": {
+                    "description": "Virtual seconds per real second, 0 pauses the clock",
+                    "type": "number"
+                }
+            }
+        },
+        "models.ClockState": {
+            "type": "object",
+            "properties": {
+                "now": {
+                    "description": "Current virtual time",
+                    "type": "string"
+                },
+                "pendingTimers": {
+                    "description": "When each scheduled wake-up (worker poll, provider answer) is due, earliest first",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "speed": {
+                    "description": "Virtual seconds per real second, 0 when paused",
+                    "type": "number"
+                }
+            }
+        },
         "models.Contact": {
             "type": "object",
             "properties": {
-                "company": {
+                "company": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "jobTitle": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "phone": {
+                    "description": "The phone as it was given, e.g. +1 (555) 123-4567",
+                    "type": "string"
+                },
+                "phoneE164": {
+                    "description": "The phone in E.164 form, e.g. +15551234567",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Bumped by every change, served as the ETag of the contact",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CreateContactRequest": {
+            "type": "object",
+            "properties": {
+                "company": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "jobTitle": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DedupeMode": {
+            "type": "string",
+            "enum": [
+                "off",
+                "existing",
+                "missing"
+            ],
+            "x-enum-varnames": [
+                "DedupeModeOff",
+                "DedupeModeExisting",
+                "DedupeModeMissing"
+            ]
+        },
+        "models.Enrichment": {
+            "type": "object",
+            "properties": {
+                "batchId": {
+                    "description": "ID of the bulk batch this enrichment belongs to",
+                    "type": "string"
+                },
+                "callbackUrl": {
+                    "description": "URL that receives the final enrichment",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "$ref": "#/definitions/models.JobStatus"
+                },
+                "error": {
+                    "description": "Why the enrichment failed, when it did not fail on a provider",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "phone": {
+                    "$ref": "#/definitions/models.JobStatus"
+                },
+                "result": {
+                    "$ref": "#/definitions/models.EnrichmentResult"
+                },
+                "retryOf": {
+                    "description": "ID of the enrichment this one retries",
+                    "type": "string"
+                },
+                "scenario": {
+                    "description": "Name of the scenario the enrichment replays",
+                    "type": "string"
+                },
+                "seed": {
+                    "description": "Seed of the random decisions, start another enrichment with it to replay them",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.EnrichmentStatus"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnrichmentContactInfo": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string"
+                },
+                "companies": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "githubUsername": {
+                    "type": "string"
+                },
+                "linkedInUrl": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "skills": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "twitterHandle": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnrichmentList": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Enrichment"
+                    }
+                },
+                "nextCursor": {
+                    "description": "Pass as cursor to fetch the next page",
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnrichmentResult": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "phone": {
+                    "description": "The phone as the provider found it",
+                    "type": "string"
+                },
+                "phoneE164": {
+                    "description": "The phone in E.164 form, e.g. +15551234567",
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnrichmentStartRequest": {
+            "type": "object",
+            "properties": {
+                "callbackUrl": {
+                    "description": "Optional URL that receives the final enrichment",
+                    "type": "string"
+                },
+                "contact": {
+                    "description": "Optional contact info to boost success rate",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EnrichmentContactInfo"
+                        }
+                    ]
+                },
+                "dedupe": {
+                    "description": "What to do when the user already has a running enrichment, defaults to \"off\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DedupeMode"
+                        }
+                    ]
+                },
+                "jobs": {
+                    "description": "Array of \"email\" and/or \"phone\"",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JobType"
+                    }
+                },
+                "providerOrder": {
+                    "description": "ProviderOrder picks the order in which each job walks through the providers",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.ProviderOrder"
+                    }
+                },
+                "scenario": {
+                    "description": "Scenario replays scripted provider outcomes instead of rolling dice, given by name or inline",
+                    "type": "object"
+                },
+                "seed": {
+                    "description": "Seed drives the provider delays and outcomes; the same seed always yields the same enrichment",
+                    "type": "integer"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnrichmentStartResponse": {
+            "type": "object",
+            "properties": {
+                "activeEnrichmentIds": {
+                    "description": "Running enrichments that already cover some of the requested jobs",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "deduplicated": {
+                    "description": "True when the request was served by already running enrichments",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "jobs": {
+                    "description": "Jobs the returned enrichment works on",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JobType"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "retryOf": {
+                    "type": "string"
+                },
+                "seed": {
+                    "description": "Seed of the started enrichment",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.EnrichmentStatus"
+                }
+            }
+        },
+        "models.EnrichmentStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "in_progress",
+                "completed",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "EnrichmentStatusPending",
+                "EnrichmentStatusInProgress",
+                "EnrichmentStatusCompleted",
+                "EnrichmentStatusFailed",
+                "EnrichmentStatusCancelled"
+            ]
+        },
+        "models.ErrorResponse": {
+            "description": "Error shape served instead of models.Problem when the server runs with LEGACY_ERRORS=true",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Every invalid field of a rejected request body",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FaultRule": {
+            "type": "object",
+            "properties": {
+                "latencyMs": {
+                    "description": "Delay of a \"latency\" fault",
+                    "type": "integer"
+                },
+                "method": {
+                    "description": "HTTP method to match, any when empty",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Route pattern such as \"/enrichment/*\", any when empty",
+                    "type": "string"
+                },
+                "probability": {
+                    "description": "Chance (0.0 to 1.0) of injecting the fault, defaults to 1",
+                    "type": "number"
+                },
+                "status": {
+                    "description": "Status code of an \"error\" fault, defaults to 500",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "\"latency\", \"error\", \"timeout\", \"drop\" or \"truncate\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FaultType"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string",
+                    "enum": [
+                        "required",
+                        "email",
+                        "phone",
+                        "phone_country_code",
+                        "phone_length"
+                    ]
+                }
+            }
+        },
+        "models.FaultType": {
+            "type": "string",
+            "enum": [
+                "latency",
+                "error",
+                "timeout",
+                "drop",
+                "truncate"
+            ],
+            "x-enum-varnames": [
+                "FaultTypeLatency",
+                "FaultTypeError",
+                "FaultTypeTimeout",
+                "FaultTypeDrop",
+                "FaultTypeTruncate"
+            ]
+        },
+        "models.JobStatus": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Every provider tried so far, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProviderAttempt"
+                    }
+                },
+                "currentProvider": {
+                    "$ref": "#/definitions/models.Provider"
+                },
+                "foundBy": {
+                    "description": "Provider that found the result",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Provider"
+                        }
+                    ]
+                },
+                "message": {
+                    "type": "string"
+                },
+                "pending": {
+                    "type": "boolean"
+                },
+                "providerOrder": {
+                    "description": "Providers the job walks through, in order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Provider"
+                    }
+                },
+                "result": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.JobType": {
+            "type": "string",
+            "enum": [
+                "phone",
+                "email"
+            ],
+            "x-enum-varnames": [
+                "JobTypePhone",
+                "JobTypeEmail"
+            ]
+        },
+        "models.LatencyDistribution": {
+            "type": "object",
+            "properties": {
+                "distribution": {
+                    "description": "\"uniform\" between minMs and maxMs, or \"normal\" around meanMs",
                     "type": "string"
                 },
-                "email": {
+                "maxMs": {
+                    "description": "Longest lookup",
+                    "type": "integer"
+                },
+                "meanMs": {
+                    "description": "Average lookup, for the normal distribution",
+                    "type": "integer"
+                },
+                "minMs": {
+                    "description": "Shortest lookup",
+                    "type": "integer"
+                },
+                "stdDevMs": {
+                    "description": "Standard deviation, for the normal distribution",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Problem": {
+            "description": "RFC 7807 problem details, served as application/problem+json",
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "Human-readable explanation of this occurrence",
                     "type": "string"
                 },
-                "firstName": {
+                "errors": {
+                    "description": "Every invalid field of a rejected request body",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "instance": {
+                    "description": "Path of the request that failed",
                     "type": "string"
                 },
-                "id": {
+                "status": {
+                    "description": "HTTP status code",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "Status text of the status code",
                     "type": "string"
                 },
-                "jobTitle": {
+                "traceId": {
+                    "description": "Also sent as the X-Trace-Id header and logged with the request",
                     "type": "string"
                 },
-                "lastName": {
+                "type": {
+                    "description": "Stable identifier of the cause, e.g. /problems/contact-not-found",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Provider": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "string"
                 },
-                "phone": {
+                "imageUrl": {
+                    "type": "string"
+                },
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "models.Enrichment": {
+        "models.ProviderAttempt": {
             "type": "object",
             "properties": {
-                "createdAt": {
+                "durationMs": {
+                    "type": "integer"
+                },
+                "error": {
                     "type": "string"
                 },
-                "email": {
-                    "$ref": "#/definitions/models.JobStatus"
+                "finishedAt": {
+                    "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "phone": {
-                    "$ref": "#/definitions/models.JobStatus"
+                "job": {
+                    "$ref": "#/definitions/models.JobType"
                 },
-                "result": {
-                    "$ref": "#/definitions/models.EnrichmentResult"
+                "outcome": {
+                    "$ref": "#/definitions/models.AttemptOutcome"
                 },
-                "status": {
-                    "$ref": "#/definitions/models.EnrichmentStatus"
+                "provider": {
+                    "$ref": "#/definitions/models.Provider"
                 },
-                "updatedAt": {
+                "providerId": {
                     "type": "string"
                 },
-                "userId": {
+                "startedAt": {
                     "type": "string"
                 }
             }
         },
-        "models.EnrichmentContactInfo": {
+        "models.ProviderConfig": {
             "type": "object",
             "properties": {
-                "bio": {
+                "creditCost": {
+                    "description": "Credits charged per lookup",
+                    "type": "integer"
+                },
+                "id": {
                     "type": "string"
                 },
-                "companies": {
+                "imageUrl": {
+                    "type": "string"
+                },
+                "jobs": {
+                    "description": "Job types the provider can look up",
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.JobType"
                     }
                 },
-                "githubUsername": {
-                    "type": "string"
+                "latency": {
+                    "description": "How long a lookup takes",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.LatencyDistribution"
+                        }
+                    ]
                 },
-                "linkedInUrl": {
-                    "type": "string"
+                "matchedSuccessRate": {
+                    "description": "Success rate when the contact info matches the third-party data",
+                    "type": "number"
                 },
-                "location": {
+                "name": {
                     "type": "string"
                 },
-                "skills": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+                "successRate": {
+                    "description": "Probability (0.0 to 1.0) of finding each job's value",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
                     }
-                },
-                "twitterHandle": {
-                    "type": "string"
                 }
             }
         },
-        "models.EnrichmentResult": {
+        "models.ProviderOrder": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
+                "providers": {
+                    "description": "Provider IDs, tried in this order; providers left out are skipped",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "phone": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.EnrichmentStartRequest": {
-            "type": "object",
-            "properties": {
-                "contact": {
-                    "description": "Optional contact info to boost success rate",
+                "strategy": {
+                    "description": "Ignored when providers is set",
                     "allOf": [
                         {
-                            "$ref": "#/definitions/models.EnrichmentContactInfo"
+                            "$ref": "#/definitions/models.ProviderStrategy"
                         }
                     ]
-                },
-                "jobs": {
-                    "description": "Array of \"email\" and/or \"phone\"",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.JobType"
-                    }
-                },
-                "userId": {
-                    "type": "string"
                 }
             }
         },
-        "models.EnrichmentStartResponse": {
+        "models.ProviderStrategy": {
+            "type": "string",
+            "enum": [
+                "default",
+                "cheapest_first",
+                "highest_hit_rate_first"
+            ],
+            "x-enum-varnames": [
+                "ProviderStrategyDefault",
+                "ProviderStrategyCheapestFirst",
+                "ProviderStrategyHighestHitRateFirst"
+            ]
+        },
+        "models.Scenario": {
             "type": "object",
             "properties": {
-                "id": {
+                "description": {
                     "type": "string"
                 },
-                "message": {
+                "jobs": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/models.ScenarioStep"
+                        }
+                    }
+                },
+                "name": {
                     "type": "string"
                 },
                 "status": {
-                    "$ref": "#/definitions/models.EnrichmentStatus"
+                    "description": "Status the enrichment ends with once its jobs are done, \"completed\" (default) or \"failed\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EnrichmentStatus"
+                        }
+                    ]
                 }
             }
         },
-        "models.EnrichmentStatus": {
-            "type": "string",
-            "enum": [
-                "pending",
-                "in_progress",
-                "completed",
-                "failed"
-            ],
-            "x-enum-varnames": [
-                "EnrichmentStatusPending",
-                "EnrichmentStatusInProgress",
-                "EnrichmentStatusCompleted",
-                "EnrichmentStatusFailed"
-            ]
-        },
-        "models.ErrorResponse": {
+        "models.ScenarioStep": {
             "type": "object",
             "properties": {
-                "code": {
+                "delayMs": {
+                    "description": "How long the provider takes to answer",
                     "type": "integer"
                 },
                 "error": {
+                    "description": "Error message of an \"error\" step",
                     "type": "string"
                 },
-                "message": {
+                "outcome": {
+                    "description": "\"found\", \"not_found\" or \"error\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AttemptOutcome"
+                        }
+                    ]
+                },
+                "value": {
+                    "description": "Value found, defaults to the contact's mock enrichment data",
                     "type": "string"
                 }
             }
         },
-        "models.JobStatus": {
+        "models.SnapshotInfo": {
             "type": "object",
             "properties": {
-                "currentProvider": {
-                    "$ref": "#/definitions/models.Provider"
+                "contacts": {
+                    "type": "integer"
                 },
-                "message": {
+                "createdAt": {
                     "type": "string"
                 },
-                "pending": {
-                    "type": "boolean"
+                "enrichments": {
+                    "type": "integer"
                 },
-                "result": {
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "models.JobType": {
-            "type": "string",
-            "enum": [
-                "phone",
-                "email"
-            ],
-            "x-enum-varnames": [
-                "JobTypePhone",
-                "JobTypeEmail"
-            ]
-        },
-        "models.Provider": {
+        "models.SnapshotRequest": {
             "type": "object",
             "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "imageUrl": {
-                    "type": "string"
-                },
                 "name": {
+                    "description": "Letters, digits, '-', '_' and '.'; taking a snapshot under an existing name replaces it",
                     "type": "string"
                 }
             }
@@ -609,6 +2242,100 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.WebSocketMessage": {
+            "type": "object",
+            "properties": {
+                "enrichment": {
+                    "description": "Current state of the enrichment",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Enrichment"
+                        }
+                    ]
+                },
+                "enrichmentIds": {
+                    "description": "Current enrichment subscriptions",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "event": {
+                    "description": "Event that triggered an \"enrichment\" message",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "\"enrichment\", \"subscribed\", \"unsubscribed\", \"pong\" or \"error\"",
+                    "type": "string"
+                },
+                "userIds": {
+                    "description": "Current user subscriptions",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.WebSocketRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "\"subscribe\", \"unsubscribe\" or \"ping\"",
+                    "type": "string"
+                },
+                "enrichmentIds": {
+                    "description": "Enrichments to (un)subscribe to",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "userId": {
+                    "description": "(Un)subscribe to every enrichment of this user",
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "durationMs": {
+                    "type": "integer"
+                },
+                "enrichmentId": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "description": "\"enrichment.completed\" or \"enrichment.failed\"",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "statusCode": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,202 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and schedules timers, so the enrichment lifecycle can run on virtual time
+type Clock interface {
+	// Now returns the current time of the clock
+	Now() time.Time
+
+	// NewTimer creates a timer that fires once d has elapsed on the clock
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer sends the clock's time on C once its duration has elapsed
+type Timer interface {
+	// C returns the channel the time is sent on
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing, returning false if it already fired or was stopped
+	Stop() bool
+}
+
+// Real is the wall clock
+type Real struct{}
+
+// Now returns the current wall clock time
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer creates a wall clock timer
+func (Real) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// realTimer adapts time.Timer to the Timer interface
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+// Virtual is a clock that runs at a multiple of the wall clock speed and can be moved forward at will.
+// A speed of 0 pauses it, so time only moves when it is advanced.
+type Virtual struct {
+	mu sync.Mutex
+	// The virtual time was virtualAnchor when the wall clock showed realAnchor
+	realAnchor    time.Time
+	virtualAnchor time.Time
+	speed         float64
+
+	timers []*virtualTimer
+	// wake fires on the wall clock when the earliest timer is due
+	wake *time.Timer
+}
+
+// NewVirtual creates a virtual clock starting at the given time and running at the given speed
+func NewVirtual(start time.Time, speed float64) *Virtual {
+	return &Virtual{
+		realAnchor:    time.Now(),
+		virtualAnchor: start,
+		speed:         speed,
+	}
+}
+
+// Now returns the current virtual time
+func (c *Virtual) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now()
+}
+
+// Speed returns how many virtual seconds pass per wall clock second
+func (c *Virtual) Speed() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.speed
+}
+
+// SetSpeed changes how many virtual seconds pass per wall clock second
+func (c *Virtual) SetSpeed(speed float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rebase()
+	c.speed = speed
+	c.fire()
+}
+
+// Advance moves the clock forward, firing every timer that becomes due.
+// Timers created in response start from the new time.
+func (c *Virtual) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rebase()
+	if d > 0 {
+		c.virtualAnchor = c.virtualAnchor.Add(d)
+	}
+	c.fire()
+}
+
+// AdvanceToNext moves the clock forward to the earliest pending timer and fires it.
+// Returns how far the clock moved, and false if no timer is pending.
+func (c *Virtual) AdvanceToNext() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.timers) == 0 {
+		return 0, false
+	}
+	c.rebase()
+	d := c.timers[0].deadline.Sub(c.virtualAnchor)
+	if d > 0 {
+		c.virtualAnchor = c.timers[0].deadline
+	} else {
+		d = 0
+	}
+	c.fire()
+	return d, true
+}
+
+// Pending returns when each pending timer is due, earliest first
+func (c *Virtual) Pending() []time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	deadlines := make([]time.Time, len(c.timers))
+	for i, t := range c.timers {
+		deadlines[i] = t.deadline
+	}
+	return deadlines
+}
+
+// NewTimer creates a timer that fires once d has elapsed on the virtual clock
+func (c *Virtual) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &virtualTimer{clock: c, deadline: c.now().Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].deadline.Before(c.timers[j].deadline) })
+	c.fire()
+	return t
+}
+
+// now returns the virtual time, c.mu must be held
+func (c *Virtual) now() time.Time {
+	elapsed := time.Since(c.realAnchor)
+	return c.virtualAnchor.Add(time.Duration(float64(elapsed) * c.speed))
+}
+
+// rebase moves the anchors to the present so the speed or virtual time can change, c.mu must be held
+func (c *Virtual) rebase() {
+	c.virtualAnchor = c.now()
+	c.realAnchor = time.Now()
+}
+
+// fire sends the time to every due timer and schedules a wake-up for the next one, c.mu must be held
+func (c *Virtual) fire() {
+	now := c.now()
+	for len(c.timers) > 0 && !c.timers[0].deadline.After(now) {
+		c.timers[0].c <- now
+		c.timers = c.timers[1:]
+	}
+
+	if c.wake != nil {
+		c.wake.Stop()
+		c.wake = nil
+	}
+	if len(c.timers) == 0 || c.speed <= 0 {
+		return
+	}
+	wait := time.Duration(float64(c.timers[0].deadline.Sub(now)) / c.speed)
+	c.wake = time.AfterFunc(wait, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.fire()
+	})
+}
+
+// virtualTimer is a timer of a virtual clock
+type virtualTimer struct {
+	clock    *Virtual
+	deadline time.Time
+	c        chan time.Time
+}
+
+func (t *virtualTimer) C() <-chan time.Time { return t.c }
+
+func (t *virtualTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+// fired reports whether a timer has fired, without waiting for it
+func fired(t Timer) bool {
+	select {
+	case <-t.C():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestVirtualAdvance(t *testing.T) {
+	tests := []struct {
+		name     string
+		advances []time.Duration
+		want     []bool // whether the 1s, 2s and 5s timers fired
+	}{
+		{name: "not advanced", want: []bool{false, false, false}},
+		{name: "short of the first timer", advances: []time.Duration{999 * time.Millisecond}, want: []bool{false, false, false}},
+		{name: "exactly to the first timer", advances: []time.Duration{time.Second}, want: []bool{true, false, false}},
+		{name: "past two timers", advances: []time.Duration{3 * time.Second}, want: []bool{true, true, false}},
+		{name: "in several steps", advances: []time.Duration{time.Second, time.Second, 2 * time.Second, time.Second}, want: []bool{true, true, true}},
+		{name: "negative is ignored", advances: []time.Duration{-time.Hour, 1500 * time.Millisecond}, want: []bool{true, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewVirtual(start, 0)
+			timers := []Timer{c.NewTimer(time.Second), c.NewTimer(2 * time.Second), c.NewTimer(5 * time.Second)}
+
+			var total time.Duration
+			for _, d := range tt.advances {
+				c.Advance(d)
+				if d > 0 {
+					total += d
+				}
+			}
+
+			if got, want := c.Now(), start.Add(total); !got.Equal(want) {
+				t.Errorf("Now() = %v, want %v", got, want)
+			}
+			pending := 0
+			for i, timer := range timers {
+				if got := fired(timer); got != tt.want[i] {
+					t.Errorf("timer %d fired = %v, want %v", i, got, tt.want[i])
+				}
+				if !tt.want[i] {
+					pending++
+				}
+			}
+			if got := len(c.Pending()); got != pending {
+				t.Errorf("Pending() has %d timers, want %d", got, pending)
+			}
+		})
+	}
+}
+
+func TestVirtualTimerStop(t *testing.T) {
+	c := NewVirtual(start, 0)
+	stopped := c.NewTimer(time.Second)
+	kept := c.NewTimer(time.Second)
+
+	if !stopped.Stop() {
+		t.Error("Stop() of a pending timer = false, want true")
+	}
+	if stopped.Stop() {
+		t.Error("second Stop() = true, want false")
+	}
+
+	c.Advance(time.Second)
+	if fired(stopped) {
+		t.Error("stopped timer fired")
+	}
+	if !fired(kept) {
+		t.Error("timer did not fire")
+	}
+	if kept.Stop() {
+		t.Error("Stop() of a fired timer = true, want false")
+	}
+}
+
+func TestVirtualAdvanceToNext(t *testing.T) {
+	c := NewVirtual(start, 0)
+	if _, ok := c.AdvanceToNext(); ok {
+		t.Fatal("AdvanceToNext() without timers = true, want false")
+	}
+
+	later := c.NewTimer(3 * time.Second)
+	sooner := c.NewTimer(time.Second)
+
+	d, ok := c.AdvanceToNext()
+	if !ok || d != time.Second {
+		t.Errorf("AdvanceToNext() = %v, %v, want 1s, true", d, ok)
+	}
+	if !fired(sooner) || fired(later) {
+		t.Error("AdvanceToNext() must fire only the earliest timer")
+	}
+
+	d, ok = c.AdvanceToNext()
+	if !ok || d != 2*time.Second || !fired(later) {
+		t.Errorf("AdvanceToNext() = %v, %v, want 2s, true", d, ok)
+	}
+	if got, want := c.Now(), start.Add(3*time.Second); !got.Equal(want) {
+		t.Errorf("Now() = %v, want %v", got, want)
+	}
+}
+
+func TestVirtualSetSpeed(t *testing.T) {
+	tests := []struct {
+		name  string
+		speed float64
+		fires bool // whether a 1 hour timer fires within 200ms of wall clock time
+	}{
+		{name: "paused", speed: 0, fires: false},
+		{name: "wall clock speed", speed: 1, fires: false},
+		{name: "fast", speed: 360000, fires: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewVirtual(start, 0)
+			timer := c.NewTimer(time.Hour)
+
+			c.SetSpeed(tt.speed)
+			if got := c.Speed(); got != tt.speed {
+				t.Errorf("Speed() = %v, want %v", got, tt.speed)
+			}
+
+			select {
+			case <-timer.C():
+				if !tt.fires {
+					t.Error("timer fired, want it pending")
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tt.fires {
+					t.Error("timer did not fire")
+				}
+			}
+
+			// Pausing freezes the clock where it is, without moving it back
+			c.SetSpeed(0)
+			frozen := c.Now()
+			if frozen.Before(start) {
+				t.Errorf("Now() = %v, before the start %v", frozen, start)
+			}
+			time.Sleep(10 * time.Millisecond)
+			if got := c.Now(); !got.Equal(frozen) {
+				t.Errorf("paused clock moved from %v to %v", frozen, got)
+			}
+		})
+	}
+}
@@ -1,7 +1,7 @@
 package data
 
 import (
-	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
@@ -33,10 +33,15 @@ const (
 // MockData holds all the mock data for the API
 // Edit this file to update mock responses
 type MockData struct {
-	mu         sync.RWMutex
+	mu sync.RWMutex
+	// Contacts holds the contacts the database is seeded with, keyed by contact ID
 	Contacts   map[string]models.Contact
 	ThirdParty map[string]models.ThirdPartyInfo
 	Providers  map[string]models.Provider
+	// ProviderConfigs holds the behaviour of each provider, keyed by provider ID
+	ProviderConfigs map[string]models.ProviderConfig
+	// Scenarios holds the scripted enrichment outcomes that can be referenced by name
+	Scenarios map[string]models.Scenario
 	// EnrichmentData stores phone/email values that can be "found" by providers
 	// Key is contact ID, value contains phone and email that providers can discover
 	EnrichmentData map[string]struct {
@@ -45,12 +50,29 @@ type MockData struct {
 	}
 }
 
+// Fixtures replaces sections of the built-in data. A nil section keeps the built-in entries,
+// an empty one removes them.
+type Fixtures struct {
+	Contacts       []models.Contact        `json:"contacts"`
+	ThirdParty     []models.ThirdPartyInfo `json:"thirdParty"`
+	EnrichmentData []EnrichmentData        `json:"enrichmentData"`
+}
+
+// EnrichmentData is the phone and email providers can find for a contact
+type EnrichmentData struct {
+	ContactID string `json:"contactId"`
+	Phone     string `json:"phone"`
+	Email     string `json:"email"`
+}
+
 // NewMockData initializes the mock data store with sample data
 func NewMockData() *MockData {
 	md := &MockData{
-		Contacts:   make(map[string]models.Contact),
-		ThirdParty: make(map[string]models.ThirdPartyInfo),
-		Providers:  make(map[string]models.Provider),
+		Contacts:        make(map[string]models.Contact),
+		ThirdParty:      make(map[string]models.ThirdPartyInfo),
+		Providers:       make(map[string]models.Provider),
+		ProviderConfigs: make(map[string]models.ProviderConfig),
+		Scenarios:       make(map[string]models.Scenario),
 		EnrichmentData: make(map[string]struct {
 			Phone string
 			Email string
@@ -217,18 +239,35 @@ func NewMockData() *MockData {
 		ImageURL: "https://dataflow.com/logo.png",
 	}
 
+	// Every provider starts with the same behaviour, see SetProviderConfigs to change it
+	for _, provider := range md.Providers {
+		md.ProviderConfigs[provider.ID] = DefaultProviderConfig(provider)
+	}
+
 	return md
 }
 
-// GetContact retrieves a contact by ID
-func (md *MockData) GetContact(id string) (models.Contact, bool) {
-	md.mu.RLock()
-	defer md.mu.RUnlock()
-	contact, exists := md.Contacts[id]
-	return contact, exists
+// DefaultProviderConfig returns the default behaviour of a provider: both job types,
+// 5 seconds ± 1 second per lookup, a 20% success rate and 80% when the contact info matches
+func DefaultProviderConfig(provider models.Provider) models.ProviderConfig {
+	return models.ProviderConfig{
+		Provider:   provider,
+		Jobs:       []models.JobType{models.JobTypePhone, models.JobTypeEmail},
+		CreditCost: 1,
+		Latency: models.LatencyDistribution{
+			Distribution: "uniform",
+			MinMs:        4000,
+			MaxMs:        6000,
+		},
+		SuccessRate: map[models.JobType]float32{
+			models.JobTypePhone: 0.2,
+			models.JobTypeEmail: 0.2,
+		},
+		MatchedSuccessRate: 0.8,
+	}
 }
 
-// GetAllContacts retrieves all contacts
+// GetAllContacts retrieves all contacts the database is seeded with
 func (md *MockData) GetAllContacts() []models.Contact {
 	md.mu.RLock()
 	defer md.mu.RUnlock()
@@ -247,7 +286,7 @@ func (md *MockData) GetThirdPartyInfo(fullName string) (models.ThirdPartyInfo, b
 	return info, exists
 }
 
-// GetAllProviders retrieves all providers
+// GetAllProviders retrieves all providers, sorted by name
 func (md *MockData) GetAllProviders() []models.Provider {
 	md.mu.RLock()
 	defer md.mu.RUnlock()
@@ -255,9 +294,72 @@ func (md *MockData) GetAllProviders() []models.Provider {
 	for _, provider := range md.Providers {
 		providers = append(providers, provider)
 	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
 	return providers
 }
 
+// GetProviderConfig retrieves the behaviour of a provider by ID
+func (md *MockData) GetProviderConfig(id string) (models.ProviderConfig, bool) {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	config, exists := md.ProviderConfigs[id]
+	return config, exists
+}
+
+// GetAllProviderConfigs retrieves the behaviour of all providers, sorted by name
+func (md *MockData) GetAllProviderConfigs() []models.ProviderConfig {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	configs := make([]models.ProviderConfig, 0, len(md.ProviderConfigs))
+	for _, config := range md.ProviderConfigs {
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	return configs
+}
+
+// SetProviderConfigs replaces all providers with the given ones
+func (md *MockData) SetProviderConfigs(configs []models.ProviderConfig) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.Providers = make(map[string]models.Provider, len(configs))
+	md.ProviderConfigs = make(map[string]models.ProviderConfig, len(configs))
+	for _, config := range configs {
+		md.Providers[config.ID] = config.Provider
+		md.ProviderConfigs[config.ID] = config
+	}
+}
+
+// GetScenario retrieves a scenario by name
+func (md *MockData) GetScenario(name string) (models.Scenario, bool) {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	scenario, exists := md.Scenarios[name]
+	return scenario, exists
+}
+
+// GetAllScenarios retrieves all scenarios, sorted by name
+func (md *MockData) GetAllScenarios() []models.Scenario {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	scenarios := make([]models.Scenario, 0, len(md.Scenarios))
+	for _, scenario := range md.Scenarios {
+		scenarios = append(scenarios, scenario)
+	}
+	sort.Slice(scenarios, func(i, j int) bool { return scenarios[i].Name < scenarios[j].Name })
+	return scenarios
+}
+
+// SetScenarios replaces all scenarios with the given ones
+func (md *MockData) SetScenarios(scenarios []models.Scenario) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.Scenarios = make(map[string]models.Scenario, len(scenarios))
+	for _, scenario := range scenarios {
+		md.Scenarios[scenario.Name] = scenario
+	}
+}
+
 // GetProvider retrieves a provider by ID
 func (md *MockData) GetProvider(id string) (models.Provider, bool) {
 	md.mu.RLock()
@@ -277,28 +379,32 @@ func (md *MockData) GetEnrichmentData(contactID string) (phone, email string, ex
 	return "", "", false
 }
 
-// UpdateContactPhone updates a contact's phone number
-func (md *MockData) UpdateContactPhone(contactID, phone string) error {
+// UseFixtures replaces the built-in contacts, third-party info and enrichment data with the fixtures' sections
+func (md *MockData) UseFixtures(fixtures Fixtures) {
 	md.mu.Lock()
 	defer md.mu.Unlock()
-	contact, exists := md.Contacts[contactID]
-	if !exists {
-		return fmt.Errorf("contact not found: %s", contactID)
+	if fixtures.Contacts != nil {
+		md.Contacts = make(map[string]models.Contact, len(fixtures.Contacts))
+		for _, contact := range fixtures.Contacts {
+			md.Contacts[contact.ID] = contact
+		}
 	}
-	contact.Phone = phone
-	md.Contacts[contactID] = contact
-	return nil
-}
-
-// UpdateContactEmail updates a contact's email
-func (md *MockData) UpdateContactEmail(contactID, email string) error {
-	md.mu.Lock()
-	defer md.mu.Unlock()
-	contact, exists := md.Contacts[contactID]
-	if !exists {
-		return fmt.Errorf("contact not found: %s", contactID)
+	if fixtures.ThirdParty != nil {
+		md.ThirdParty = make(map[string]models.ThirdPartyInfo, len(fixtures.ThirdParty))
+		for _, info := range fixtures.ThirdParty {
+			md.ThirdParty[strings.ToLower(info.FullName)] = info
+		}
+	}
+	if fixtures.EnrichmentData != nil {
+		md.EnrichmentData = make(map[string]struct {
+			Phone string
+			Email string
+		}, len(fixtures.EnrichmentData))
+		for _, data := range fixtures.EnrichmentData {
+			md.EnrichmentData[data.ContactID] = struct {
+				Phone string
+				Email string
+			}{Phone: data.Phone, Email: data.Email}
+		}
 	}
-	contact.Email = email
-	md.Contacts[contactID] = contact
-	return nil
 }
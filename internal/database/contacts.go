@@ -0,0 +1,390 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/normalize"
+)
+
+// contactColumns are the columns scanned by scanContact, in order
+const contactColumns = "id, first_name, last_name, email, phone, phone_e164, company, job_title, version"
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// SetSeedContacts sets the contacts created by SeedContacts, such as the built-in or fixture contacts
+func (db *DB) SetSeedContacts(contacts []models.Contact) {
+	db.seedContacts = contacts
+}
+
+// SeedContacts creates the seed contacts that don't exist yet, normalizing their phone and email
+func (db *DB) SeedContacts() error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin seeding contacts: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := db.insertSeedContacts(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit seed contacts: %w", err)
+	}
+	return nil
+}
+
+// insertSeedContacts creates the seed contacts that don't exist yet within tx
+func (db *DB) insertSeedContacts(tx *sql.Tx) error {
+	now := db.now().Format(time.RFC3339)
+
+	for _, contact := range db.seedContacts {
+		contact, errs := normalize.Contact(contact)
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to seed contact %s: %s", contact.ID, errs[0].Message)
+		}
+
+		res, err := tx.Exec(`
+			INSERT OR IGNORE INTO contacts (id, first_name, last_name, email, phone, phone_e164, company, job_title, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, contact.ID, contact.FirstName, contact.LastName, contact.Email, contact.Phone, contact.PhoneE164, contact.Company, contact.JobTitle, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to seed contact %s: %w", contact.ID, err)
+		}
+
+		if affected, _ := res.RowsAffected(); affected > 0 {
+			log.Printf("Seeded contact: %s (%s %s)", contact.ID, contact.FirstName, contact.LastName)
+		}
+	}
+
+	return nil
+}
+
+// GetContact retrieves a contact by ID
+// Returns nil if the contact does not exist
+func (db *DB) GetContact(id string) (*models.Contact, error) {
+	contact, err := scanContact(db.conn.QueryRow(`SELECT `+contactColumns+` FROM contacts WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact: %w", err)
+	}
+
+	return contact, nil
+}
+
+// contactSortExpressions maps the sort keys of ContactFilter to the expression contacts are ordered by
+var contactSortExpressions = map[string]string{
+	"name":       "(last_name || ' ' || first_name) COLLATE NOCASE",
+	"first_name": "first_name COLLATE NOCASE",
+	"last_name":  "last_name COLLATE NOCASE",
+	"email":      "email COLLATE NOCASE",
+	"phone":      "phone_e164",
+	"company":    "company COLLATE NOCASE",
+	"job_title":  "job_title COLLATE NOCASE",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// ContactFilter describes which contacts ListContacts returns
+type ContactFilter struct {
+	Search   string // case-insensitive substring of the name, email, company or job title
+	Company  string // case-insensitive exact company
+	HasPhone *bool
+	HasEmail *bool
+	SortBy   string // one of the keys of contactSortExpressions, "name" (last then first name) by default
+	// Descending reverses the order, ties are always broken by ID so pages never shuffle
+	Descending bool
+	Limit      int // 0 returns every remaining contact
+	// AfterValue and AfterID continue a listing after the row with this sort value and ID
+	AfterValue string
+	AfterID    string
+}
+
+// ContactPage is a page of contacts returned by ListContacts
+type ContactPage struct {
+	Contacts []models.Contact
+	// Total counts the contacts matching the filter across all pages
+	Total int
+	// NextValue and NextID continue the listing on the next page; NextID is empty on the last page
+	NextValue string
+	NextID    string
+}
+
+// ListContacts returns a page of the contacts matching the filter, ordered by the sort expression and ID
+func (db *DB) ListContacts(filter ContactFilter) (*ContactPage, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = "name"
+	}
+	sortExpression, ok := contactSortExpressions[filter.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid contact sort: %s", filter.SortBy)
+	}
+	direction := "ASC"
+	comparison := ">"
+	if filter.Descending {
+		direction = "DESC"
+		comparison = "<"
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	if filter.Search != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Search) + "%"
+		var matches []string
+		for _, column := range []string{"first_name", "last_name", "first_name || ' ' || last_name", "email", "company", "job_title"} {
+			matches = append(matches, column+` LIKE ? ESCAPE '\'`)
+			args = append(args, pattern)
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+	}
+	if filter.Company != "" {
+		conditions = append(conditions, "company = ? COLLATE NOCASE")
+		args = append(args, filter.Company)
+	}
+	if filter.HasPhone != nil {
+		conditions = append(conditions, presenceCondition("phone", *filter.HasPhone))
+	}
+	if filter.HasEmail != nil {
+		conditions = append(conditions, presenceCondition("email", *filter.HasEmail))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = `
+		WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	// A single transaction keeps the total consistent with the page
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin listing contacts: %w", err)
+	}
+	defer tx.Rollback()
+
+	page := &ContactPage{Contacts: []models.Contact{}}
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM contacts`+where, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count contacts: %w", err)
+	}
+
+	if filter.AfterID != "" {
+		if where == "" {
+			where = `
+		WHERE `
+		} else {
+			where += " AND "
+		}
+		where += "(" + sortExpression + ", id) " + comparison + " (?, ?)"
+		args = append(args, filter.AfterValue, filter.AfterID)
+	}
+
+	query := `
+		SELECT ` + contactColumns + `, ` + sortExpression + `
+		FROM contacts` + where + `
+		ORDER BY ` + sortExpression + ` ` + direction + `, id ` + direction
+	if filter.Limit > 0 {
+		// Fetch one extra row to know whether there is a next page
+		query += `
+		LIMIT ?`
+		args = append(args, filter.Limit+1)
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+	defer rows.Close()
+
+	var sortValues []string
+	for rows.Next() {
+		var contact models.Contact
+		var sortValue string
+		if err := rows.Scan(&contact.ID, &contact.FirstName, &contact.LastName, &contact.Email, &contact.Phone, &contact.PhoneE164, &contact.Company, &contact.JobTitle, &contact.Version, &sortValue); err != nil {
+			return nil, fmt.Errorf("failed to scan contact: %w", err)
+		}
+		page.Contacts = append(page.Contacts, contact)
+		sortValues = append(sortValues, sortValue)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+
+	if filter.Limit > 0 && len(page.Contacts) > filter.Limit {
+		page.Contacts = page.Contacts[:filter.Limit]
+		page.NextValue = sortValues[filter.Limit-1]
+		page.NextID = page.Contacts[filter.Limit-1].ID
+	}
+
+	return page, nil
+}
+
+// CreateContact inserts a new contact at version 1, failing if its ID is already taken, and returns it.
+// The contact is expected to be normalized already, see normalize.Contact.
+func (db *DB) CreateContact(contact models.Contact) (*models.Contact, error) {
+	now := db.now().Format(time.RFC3339)
+	contact.Version = 1
+
+	_, err := db.conn.Exec(`
+		INSERT INTO contacts (id, first_name, last_name, email, phone, phone_e164, company, job_title, version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, contact.ID, contact.FirstName, contact.LastName, contact.Email, contact.Phone, contact.PhoneE164, contact.Company, contact.JobTitle, contact.Version, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create contact: %w", err)
+	}
+
+	return &contact, nil
+}
+
+// UpdateContact updates the phone and/or email of a contact, leaving nil ones as they are; an empty
+// phone or email clears it. Both are expected to be normalized already.
+// A non-zero version only updates the contact if it is still at that version.
+// Returns nil if the contact does not exist, and whether it was updated; if not, the contact is returned as it is.
+func (db *DB) UpdateContact(id string, version int, phone *normalize.Phone, email *string) (*models.Contact, bool, error) {
+	var display, e164 *string
+	if phone != nil {
+		display, e164 = &phone.Display, &phone.E164
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin contact update: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := db.now().Format(time.RFC3339)
+	res, err := tx.Exec(`
+		UPDATE contacts
+		SET phone = COALESCE(?, phone), phone_e164 = COALESCE(?, phone_e164), email = COALESCE(?, email),
+			version = version + 1, updated_at = ?
+		WHERE id = ? AND (? = 0 OR version = ?)
+	`, display, e164, email, now, id, version, version)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to update contact: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check updated contact: %w", err)
+	}
+
+	contact, err := scanContact(tx.QueryRow(`SELECT `+contactColumns+` FROM contacts WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get updated contact: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit contact update: %w", err)
+	}
+
+	return contact, affected > 0, nil
+}
+
+// DeleteContact deletes a contact and, in the same transaction, fails its pending and in_progress
+// enrichments with the given reason. Returns whether the contact existed and the IDs of the failed enrichments.
+func (db *DB) DeleteContact(id, reason string) (bool, []string, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to begin contact deletion: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM contacts WHERE id = ?`, id)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to delete contact: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return false, nil, fmt.Errorf("failed to check deleted contact: %w", err)
+	} else if affected == 0 {
+		return false, nil, nil
+	}
+
+	failed, err := db.failUserEnrichments(tx, id, reason)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, nil, fmt.Errorf("failed to commit contact deletion: %w", err)
+	}
+
+	return true, failed, nil
+}
+
+// RecordFoundValue normalizes the value a provider found for a job and stores it in the enrichment result
+// and on the contact, in one transaction. Returns the stored value and whether the contact still existed to be updated.
+func (db *DB) RecordFoundValue(enrichmentID, contactID, jobType, value string) (string, bool, error) {
+	stored, e164, err := normalizeJobValue(jobType, value)
+	if err != nil {
+		return "", false, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to begin recording found value: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := db.now().Format(time.RFC3339)
+
+	// json_set only touches this job's fields, preserving the other job's
+	resultUpdate := `json_set(COALESCE(NULLIF(result, ''), '{}'), '$.email', ?)`
+	contactUpdate := `email = ?`
+	args := []any{stored}
+	if jobType == string(models.JobTypePhone) {
+		resultUpdate = `json_set(COALESCE(NULLIF(result, ''), '{}'), '$.phone', ?, '$.phoneE164', ?)`
+		contactUpdate = `phone = ?, phone_e164 = ?`
+		args = append(args, e164)
+	}
+
+	_, err = tx.Exec(`UPDATE enrichments SET updated_at = ?, result = `+resultUpdate+` WHERE id = ?`, append(append([]any{now}, args...), enrichmentID)...)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to update enrichment result: %w", err)
+	}
+
+	// Bumping the version makes edits based on the contact as it was before fail their If-Match
+	res, err := tx.Exec(`UPDATE contacts SET `+contactUpdate+`, version = version + 1, updated_at = ? WHERE id = ?`, append(args, now, contactID)...)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to update contact %s: %w", jobType, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to check updated contact: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", false, fmt.Errorf("failed to commit found value: %w", err)
+	}
+
+	return stored, affected > 0, nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, for use with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// presenceCondition matches the rows where a text column is set, or empty when present is false
+func presenceCondition(column string, present bool) string {
+	if present {
+		return column + " != ''"
+	}
+	return column + " = ''"
+}
+
+// scanContact reads a row of contactColumns
+func scanContact(row rowScanner) (*models.Contact, error) {
+	var contact models.Contact
+	if err := row.Scan(&contact.ID, &contact.FirstName, &contact.LastName, &contact.Email, &contact.Phone, &contact.PhoneE164, &contact.Company, &contact.JobTitle, &contact.Version); err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
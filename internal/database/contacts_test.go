@@ -0,0 +1,79 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/surfe/mock-api/internal/models"
+)
+
+func TestListContactsKeyset(t *testing.T) {
+	db, _ := newTestDB(t)
+
+	// Names, emails, phones and companies repeat so the ID has to break the ties
+	contacts := []models.Contact{
+		{ID: "c5", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Company: "Acme"},
+		{ID: "c1", FirstName: "jane", LastName: "doe", Email: "jane@example.com", Phone: "+1 555 010 0002", PhoneE164: "+15550100002", Company: "acme"},
+		{ID: "c3", FirstName: "John", LastName: "Doe", Phone: "+15550100001", PhoneE164: "+15550100001", Company: "Globex"},
+		{ID: "c2", FirstName: "Ann", LastName: "Smith", Email: "ann@example.com", Phone: "+15550100002", PhoneE164: "+15550100002"},
+		{ID: "c4", FirstName: "Bob", LastName: "Adams", Company: "Acme"},
+		{ID: "c6", FirstName: "Zed", LastName: "Young", Email: "zed@example.com"},
+	}
+	for _, c := range contacts {
+		if _, err := db.CreateContact(c); err != nil {
+			t.Fatalf("CreateContact: %v", err)
+		}
+	}
+
+	hasPhone := true
+	tests := []struct {
+		name   string
+		filter ContactFilter
+		want   []string // every matching contact ID, in order
+	}{
+		{name: "name", filter: ContactFilter{SortBy: "name"}, want: []string{"c4", "c1", "c5", "c3", "c2", "c6"}},
+		{name: "name descending", filter: ContactFilter{SortBy: "name", Descending: true}, want: []string{"c6", "c2", "c3", "c5", "c1", "c4"}},
+		{name: "email", filter: ContactFilter{SortBy: "email"}, want: []string{"c3", "c4", "c2", "c1", "c5", "c6"}},
+		{name: "phone", filter: ContactFilter{SortBy: "phone", HasPhone: &hasPhone}, want: []string{"c3", "c1", "c2"}},
+		{name: "company descending", filter: ContactFilter{SortBy: "company", Descending: true}, want: []string{"c3", "c5", "c4", "c1", "c6", "c2"}},
+		{name: "company filter", filter: ContactFilter{Company: "ACME"}, want: []string{"c4", "c1", "c5"}},
+	}
+
+	for _, tt := range tests {
+		for _, limit := range []int{0, 1, 2, 4} {
+			filter := tt.filter
+			filter.Limit = limit
+
+			var got []string
+			for pages := 0; ; pages++ {
+				if pages > len(contacts) {
+					t.Fatalf("%s by %d: listing did not end, got %v", tt.name, limit, got)
+				}
+				page, err := db.ListContacts(filter)
+				if err != nil {
+					t.Fatalf("%s by %d: ListContacts: %v", tt.name, limit, err)
+				}
+				if page.Total != len(tt.want) {
+					t.Errorf("%s by %d: total = %d, want %d", tt.name, limit, page.Total, len(tt.want))
+				}
+				for _, c := range page.Contacts {
+					got = append(got, c.ID)
+				}
+				if page.NextID == "" {
+					break
+				}
+				filter.AfterValue, filter.AfterID = page.NextValue, page.NextID
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("%s by %d: got %v, want %v", tt.name, limit, got, tt.want)
+				continue
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("%s by %d: got %v, want %v", tt.name, limit, got, tt.want)
+					break
+				}
+			}
+		}
+	}
+}
@@ -7,21 +7,30 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
 
+	"github.com/surfe/mock-api/internal/clock"
 	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/normalize"
 )
 
 // DB wraps the SQL database connection
 type DB struct {
 	conn *sql.DB
+	// clock stamps every record and decides which enrichments are old enough to process
+	clock clock.Clock
+	// staticEnrichments replace the built-in static enrichments when set
+	staticEnrichments []StaticEnrichment
+	// seedContacts are the contacts SeedContacts creates
+	seedContacts []models.Contact
 }
 
 // New creates a new database connection and initializes the schema
-func New(dbPath string) (*DB, error) {
+func New(dbPath string, clk clock.Clock) (*DB, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
 	if dir != "" && dir != "." {
@@ -54,7 +63,7 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to test database connection: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, clock: clk}
 
 	if err := db.migrate(); err != nil {
 		conn.Close()
@@ -94,11 +103,76 @@ func (db *DB) migrate() error {
 		jobs TEXT,
 		completed_jobs TEXT,
 		contact_info TEXT,
-		is_static INTEGER DEFAULT 0
+		is_static INTEGER DEFAULT 0,
+		retry_of TEXT,
+		callback_url TEXT,
+		batch_id TEXT,
+		provider_order TEXT,
+		seed INTEGER,
+		scenario TEXT,
+		failure_reason TEXT
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_enrichments_status ON enrichments(status);
 	CREATE INDEX IF NOT EXISTS idx_enrichments_created_at ON enrichments(created_at);
+
+	CREATE TABLE IF NOT EXISTS contacts (
+		id TEXT PRIMARY KEY,
+		first_name TEXT NOT NULL,
+		last_name TEXT NOT NULL,
+		email TEXT NOT NULL DEFAULT '',
+		phone TEXT NOT NULL DEFAULT '',
+		phone_e164 TEXT NOT NULL DEFAULT '',
+		company TEXT NOT NULL DEFAULT '',
+		job_title TEXT NOT NULL DEFAULT '',
+		version INTEGER NOT NULL DEFAULT 1,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS enrichment_batches (
+		id TEXT PRIMARY KEY,
+		created_at TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		enrichment_id TEXT NOT NULL,
+		event TEXT NOT NULL,
+		url TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		status_code INTEGER,
+		error TEXT,
+		success INTEGER NOT NULL DEFAULT 0,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_enrichment_id ON webhook_deliveries(enrichment_id);
+
+	CREATE TABLE IF NOT EXISTS provider_attempts (
+		id TEXT PRIMARY KEY,
+		enrichment_id TEXT NOT NULL,
+		job TEXT NOT NULL,
+		provider_id TEXT NOT NULL,
+		outcome TEXT NOT NULL,
+		error TEXT,
+		started_at TEXT NOT NULL,
+		finished_at TEXT,
+		duration_ms INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_provider_attempts_enrichment_id ON provider_attempts(enrichment_id);
+
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key TEXT PRIMARY KEY,
+		request_hash TEXT NOT NULL,
+		status_code INTEGER NOT NULL,
+		response TEXT NOT NULL,
+		created_at TEXT NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
 	`
 
 	if _, err := db.conn.Exec(schema); err != nil {
@@ -126,6 +200,16 @@ func (db *DB) migrate() error {
 	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN jobs TEXT`)
 	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN completed_jobs TEXT`)
 	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN contact_info TEXT`)
+	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN retry_of TEXT`)
+	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN callback_url TEXT`)
+	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN batch_id TEXT`)
+	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN provider_order TEXT`)
+	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN seed INTEGER`)
+	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN scenario TEXT`)
+	_, _ = db.conn.Exec(`ALTER TABLE enrichments ADD COLUMN failure_reason TEXT`)
+	_, _ = db.conn.Exec(`ALTER TABLE contacts ADD COLUMN phone_e164 TEXT NOT NULL DEFAULT ''`)
+	_, _ = db.conn.Exec(`ALTER TABLE contacts ADD COLUMN version INTEGER NOT NULL DEFAULT 1`)
+	_, _ = db.conn.Exec(`CREATE INDEX IF NOT EXISTS idx_enrichments_batch_id ON enrichments(batch_id)`)
 
 	return nil
 }
@@ -135,20 +219,66 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// EnrichmentOptions describes a new enrichment record
+type EnrichmentOptions struct {
+	UserID      string
+	Jobs        []string
+	ContactInfo *models.EnrichmentContactInfo
+	// RetryOf links the enrichment to the one it retries
+	RetryOf string
+	// CallbackURL receives the final enrichment once it completes or fails
+	CallbackURL string
+	// BatchID groups enrichments started together through the bulk endpoint
+	BatchID string
+	// ProviderOrder holds, per job type, the IDs of the providers to walk through in order
+	ProviderOrder map[string][]string
+	// Seed drives every random decision the worker makes for the enrichment
+	Seed *int64
+	// Scenario is replayed by the worker instead of rolling dice
+	Scenario *models.Scenario
+}
+
 // CreateEnrichment creates a new enrichment record
 func (db *DB) CreateEnrichment(userID string, jobs []string, contactInfo *models.EnrichmentContactInfo) (*models.Enrichment, error) {
+	return db.CreateEnrichmentWithOptions(EnrichmentOptions{
+		UserID:      userID,
+		Jobs:        jobs,
+		ContactInfo: contactInfo,
+	})
+}
+
+// CreateEnrichmentWithOptions creates a new enrichment record from the given options
+func (db *DB) CreateEnrichmentWithOptions(opts EnrichmentOptions) (*models.Enrichment, error) {
+	return db.insertEnrichment(db.conn, opts)
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertEnrichment inserts an enrichment record from the given options through conn
+func (db *DB) insertEnrichment(conn execer, opts EnrichmentOptions) (*models.Enrichment, error) {
 	id := uuid.New().String()
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := db.now().Format(time.RFC3339)
 
 	enrichment := &models.Enrichment{
-		ID:        id,
-		UserID:    userID,
-		Status:    models.EnrichmentStatusPending,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:          id,
+		UserID:      opts.UserID,
+		Status:      models.EnrichmentStatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		RetryOf:     opts.RetryOf,
+		CallbackURL: opts.CallbackURL,
+		BatchID:     opts.BatchID,
+		Seed:        opts.Seed,
+	}
+	if opts.Scenario != nil {
+		enrichment.Scenario = opts.Scenario.Name
 	}
 
 	// Default to phone if no jobs specified
+	jobs := opts.Jobs
 	if len(jobs) == 0 {
 		jobs = []string{"phone"}
 	}
@@ -159,10 +289,32 @@ func (db *DB) CreateEnrichment(userID string, jobs []string, contactInfo *models
 		return nil, fmt.Errorf("failed to marshal jobs: %w", err)
 	}
 
+	// Marshal provider order to JSON if provided
+	var providerOrderJSON *string
+	if len(opts.ProviderOrder) > 0 {
+		orderData, err := json.Marshal(opts.ProviderOrder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal provider order: %w", err)
+		}
+		s := string(orderData)
+		providerOrderJSON = &s
+	}
+
+	// Marshal scenario to JSON if provided, so later changes to a named scenario do not affect it
+	var scenarioJSON *string
+	if opts.Scenario != nil {
+		scenarioData, err := json.Marshal(opts.Scenario)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal scenario: %w", err)
+		}
+		s := string(scenarioData)
+		scenarioJSON = &s
+	}
+
 	// Marshal contact info to JSON if provided
 	var contactInfoJSON *string
-	if contactInfo != nil {
-		contactData, err := json.Marshal(contactInfo)
+	if opts.ContactInfo != nil {
+		contactData, err := json.Marshal(opts.ContactInfo)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal contact info: %w", err)
 		}
@@ -170,10 +322,10 @@ func (db *DB) CreateEnrichment(userID string, jobs []string, contactInfo *models
 		contactInfoJSON = &s
 	}
 
-	_, err = db.conn.Exec(`
-		INSERT INTO enrichments (id, user_id, status, created_at, updated_at, current_provider_id, phone_provider_id, email_provider_id, jobs, completed_jobs, contact_info, is_static)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)
-	`, enrichment.ID, enrichment.UserID, enrichment.Status, enrichment.CreatedAt, enrichment.UpdatedAt, nil, nil, nil, string(jobsJSON), "[]", contactInfoJSON)
+	_, err = conn.Exec(`
+		INSERT INTO enrichments (id, user_id, status, created_at, updated_at, current_provider_id, phone_provider_id, email_provider_id, jobs, completed_jobs, contact_info, is_static, retry_of, callback_url, batch_id, provider_order, seed, scenario)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?, ?, ?, ?)
+	`, enrichment.ID, enrichment.UserID, enrichment.Status, enrichment.CreatedAt, enrichment.UpdatedAt, nil, nil, nil, string(jobsJSON), "[]", contactInfoJSON, nullString(opts.RetryOf), nullString(opts.CallbackURL), nullString(opts.BatchID), providerOrderJSON, opts.Seed, scenarioJSON)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create enrichment: %w", err)
@@ -191,12 +343,18 @@ func (db *DB) GetEnrichment(id string) (*models.Enrichment, error) {
 	var emailProviderID sql.NullString
 	var jobsJSON sql.NullString
 	var completedJobsJSON sql.NullString
+	var retryOf sql.NullString
+	var callbackURL sql.NullString
+	var batchID sql.NullString
+	var seed sql.NullInt64
+	var scenarioName sql.NullString
+	var failureReason sql.NullString
 
 	err := db.conn.QueryRow(`
-		SELECT id, user_id, status, created_at, updated_at, result, current_provider_id, phone_provider_id, email_provider_id, jobs, completed_jobs
+		SELECT id, user_id, status, created_at, updated_at, result, current_provider_id, phone_provider_id, email_provider_id, jobs, completed_jobs, retry_of, callback_url, batch_id, seed, json_extract(scenario, '$.name'), failure_reason
 		FROM enrichments
 		WHERE id = ?
-	`, id).Scan(&enrichment.ID, &enrichment.UserID, &enrichment.Status, &enrichment.CreatedAt, &enrichment.UpdatedAt, &resultJSON, &currentProviderID, &phoneProviderID, &emailProviderID, &jobsJSON, &completedJobsJSON)
+	`, id).Scan(&enrichment.ID, &enrichment.UserID, &enrichment.Status, &enrichment.CreatedAt, &enrichment.UpdatedAt, &resultJSON, &currentProviderID, &phoneProviderID, &emailProviderID, &jobsJSON, &completedJobsJSON, &retryOf, &callbackURL, &batchID, &seed, &scenarioName, &failureReason)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -213,8 +371,27 @@ func (db *DB) GetEnrichment(id string) (*models.Enrichment, error) {
 		enrichment.Result = &result
 	}
 
-	// Store provider IDs for handler to populate JobStatus objects
-	// The handler will populate the Phone and Email JobStatus objects
+	if retryOf.Valid {
+		enrichment.RetryOf = retryOf.String
+	}
+	if callbackURL.Valid {
+		enrichment.CallbackURL = callbackURL.String
+	}
+	if batchID.Valid {
+		enrichment.BatchID = batchID.String
+	}
+	if seed.Valid {
+		enrichment.Seed = &seed.Int64
+	}
+	if scenarioName.Valid {
+		enrichment.Scenario = scenarioName.String
+	}
+	if failureReason.Valid {
+		enrichment.Error = failureReason.String
+	}
+
+	// Store provider IDs for GetEnrichmentDetails to populate JobStatus objects
+	// GetEnrichmentDetails will populate the Phone and Email JobStatus objects
 	if phoneProviderID.Valid && phoneProviderID.String != "" {
 		// This will be used by the handler
 	}
@@ -254,6 +431,66 @@ func (db *DB) GetEnrichmentContactInfo(id string) (*models.EnrichmentContactInfo
 	return &contactInfo, nil
 }
 
+// GetEnrichmentProviderOrder retrieves, per job type, the IDs of the providers the enrichment walks through
+// Returns nil if no order was stored
+func (db *DB) GetEnrichmentProviderOrder(id string) (map[string][]string, error) {
+	var providerOrderJSON sql.NullString
+
+	err := db.conn.QueryRow(`
+		SELECT provider_order
+		FROM enrichments
+		WHERE id = ?
+	`, id).Scan(&providerOrderJSON)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrichment provider order: %w", err)
+	}
+
+	if !providerOrderJSON.Valid || providerOrderJSON.String == "" {
+		return nil, nil
+	}
+
+	var order map[string][]string
+	if err := json.Unmarshal([]byte(providerOrderJSON.String), &order); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal provider order: %w", err)
+	}
+
+	return order, nil
+}
+
+// GetEnrichmentScenario retrieves the scenario an enrichment replays
+// Returns nil if the enrichment has no scenario
+func (db *DB) GetEnrichmentScenario(id string) (*models.Scenario, error) {
+	var scenarioJSON sql.NullString
+
+	err := db.conn.QueryRow(`
+		SELECT scenario
+		FROM enrichments
+		WHERE id = ?
+	`, id).Scan(&scenarioJSON)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrichment scenario: %w", err)
+	}
+
+	if !scenarioJSON.Valid || scenarioJSON.String == "" {
+		return nil, nil
+	}
+
+	var scenario models.Scenario
+	if err := json.Unmarshal([]byte(scenarioJSON.String), &scenario); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scenario: %w", err)
+	}
+
+	return &scenario, nil
+}
+
 // GetEnrichmentWithProviders retrieves an enrichment with provider IDs for phone and email
 func (db *DB) GetEnrichmentWithProviders(id string) (*models.Enrichment, *string, *string, error) {
 	enrichment, err := db.GetEnrichment(id)
@@ -290,6 +527,178 @@ func (db *DB) GetEnrichmentWithProviders(id string) (*models.Enrichment, *string
 	return enrichment, phoneProviderIDPtr, emailProviderIDPtr, nil
 }
 
+// ProviderLookup resolves a provider by ID, such as MockData.GetProvider
+type ProviderLookup func(id string) (models.Provider, bool)
+
+// GetEnrichmentDetails retrieves an enrichment and populates the Phone and Email JobStatus objects
+// Returns nil if the enrichment does not exist
+func (db *DB) GetEnrichmentDetails(id string, lookupProvider ProviderLookup) (*models.Enrichment, error) {
+	enrichment, phoneProviderID, emailProviderID, err := db.GetEnrichmentWithProviders(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrichment: %w", err)
+	}
+	if enrichment == nil {
+		return nil, nil
+	}
+
+	// Get jobs and completed jobs to determine status
+	jobs, completedJobs, err := db.GetEnrichmentJobs(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrichment jobs: %w", err)
+	}
+
+	providerOrder, err := db.GetEnrichmentProviderOrder(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrichment provider order: %w", err)
+	}
+	attempts, err := db.GetProviderAttempts(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider attempts: %w", err)
+	}
+	// jobAttempts returns the attempts of one job, and the provider that found its value
+	jobAttempts := func(job string) ([]models.ProviderAttempt, *models.Provider) {
+		result := []models.ProviderAttempt{}
+		var foundBy *models.Provider
+		for _, attempt := range attempts {
+			if string(attempt.Job) != job {
+				continue
+			}
+			if provider, exists := lookupProvider(attempt.ProviderID); exists {
+				attempt.Provider = &provider
+				if attempt.Outcome == models.AttemptOutcomeFound {
+					foundBy = attempt.Provider
+				}
+			}
+			result = append(result, attempt)
+		}
+		return result, foundBy
+	}
+
+	resolveOrder := func(job string) []models.Provider {
+		var providers []models.Provider
+		for _, providerID := range providerOrder[job] {
+			if provider, exists := lookupProvider(providerID); exists {
+				providers = append(providers, provider)
+			}
+		}
+		return providers
+	}
+
+	// Populate Phone JobStatus
+	phoneRequested := false
+	phoneCompleted := false
+	for _, job := range jobs {
+		if job == "phone" {
+			phoneRequested = true
+			break
+		}
+	}
+	for _, completed := range completedJobs {
+		if completed == "phone" {
+			phoneCompleted = true
+			break
+		}
+	}
+
+	if phoneRequested {
+		phoneStatus := &models.JobStatus{
+			Pending:       !phoneCompleted,
+			ProviderOrder: resolveOrder("phone"),
+		}
+		phoneStatus.Attempts, phoneStatus.FoundBy = jobAttempts("phone")
+
+		// Set current provider
+		if phoneProviderID != nil && *phoneProviderID != "" {
+			provider, exists := lookupProvider(*phoneProviderID)
+			if exists {
+				phoneStatus.CurrentProvider = &provider
+			}
+		}
+
+		// Set result and message
+		if phoneCompleted {
+			if enrichment.Result != nil && enrichment.Result.Phone != "" {
+				phoneStatus.Result = enrichment.Result.Phone
+				phoneStatus.Message = "Phone number found successfully"
+			} else {
+				phoneStatus.Result = ""
+				phoneStatus.Message = "Phone number not found after checking all providers"
+			}
+		} else if enrichment.Status == models.EnrichmentStatusCancelled {
+			phoneStatus.Pending = false
+			phoneStatus.Message = "Phone number search cancelled"
+		} else if enrichment.Status == models.EnrichmentStatusFailed {
+			phoneStatus.Pending = false
+			phoneStatus.Message = "Phone number search failed"
+			if enrichment.Error != "" {
+				phoneStatus.Message += ": " + enrichment.Error
+			}
+		} else {
+			phoneStatus.Message = "Searching for phone number..."
+		}
+
+		enrichment.Phone = phoneStatus
+	}
+
+	// Populate Email JobStatus
+	emailRequested := false
+	emailCompleted := false
+	for _, job := range jobs {
+		if job == "email" {
+			emailRequested = true
+			break
+		}
+	}
+	for _, completed := range completedJobs {
+		if completed == "email" {
+			emailCompleted = true
+			break
+		}
+	}
+
+	if emailRequested {
+		emailStatus := &models.JobStatus{
+			Pending:       !emailCompleted,
+			ProviderOrder: resolveOrder("email"),
+		}
+		emailStatus.Attempts, emailStatus.FoundBy = jobAttempts("email")
+
+		// Set current provider
+		if emailProviderID != nil && *emailProviderID != "" {
+			provider, exists := lookupProvider(*emailProviderID)
+			if exists {
+				emailStatus.CurrentProvider = &provider
+			}
+		}
+
+		// Set result and message
+		if emailCompleted {
+			if enrichment.Result != nil && enrichment.Result.Email != "" {
+				emailStatus.Result = enrichment.Result.Email
+				emailStatus.Message = "Email found successfully"
+			} else {
+				emailStatus.Result = ""
+				emailStatus.Message = "Email not found after checking all providers"
+			}
+		} else if enrichment.Status == models.EnrichmentStatusCancelled {
+			emailStatus.Pending = false
+			emailStatus.Message = "Email search cancelled"
+		} else if enrichment.Status == models.EnrichmentStatusFailed {
+			emailStatus.Pending = false
+			emailStatus.Message = "Email search failed"
+			if enrichment.Error != "" {
+				emailStatus.Message += ": " + enrichment.Error
+			}
+		} else {
+			emailStatus.Message = "Searching for email..."
+		}
+
+		enrichment.Email = emailStatus
+	}
+
+	return enrichment, nil
+}
+
 // GetEnrichmentJobs retrieves the jobs and completed jobs for an enrichment
 func (db *DB) GetEnrichmentJobs(id string) ([]string, []string, error) {
 	var jobsJSON sql.NullString
@@ -328,17 +737,21 @@ func (db *DB) GetEnrichmentJobs(id string) ([]string, []string, error) {
 	return jobs, completedJobs, nil
 }
 
-// AddCompletedJob adds a job to the completed jobs list
-func (db *DB) AddCompletedJob(enrichmentID, job string) error {
+// notTerminal matches enrichments that have not reached a final status yet
+const notTerminal = "status NOT IN ('" + string(models.EnrichmentStatusCancelled) + "', '" + string(models.EnrichmentStatusFailed) + "', '" + string(models.EnrichmentStatusCompleted) + "')"
+
+// AddCompletedJob adds a job to the completed jobs list.
+// Returns true when this was the last job and the enrichment was marked as completed.
+func (db *DB) AddCompletedJob(enrichmentID, job string) (bool, error) {
 	jobs, completedJobs, err := db.GetEnrichmentJobs(enrichmentID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	// Check if job is already completed
 	for _, completed := range completedJobs {
 		if completed == job {
-			return nil // Already completed
+			return false, nil // Already completed
 		}
 	}
 
@@ -364,55 +777,184 @@ func (db *DB) AddCompletedJob(enrichmentID, job string) error {
 	// Marshal completed jobs
 	completedJobsJSON, err := json.Marshal(completedJobs)
 	if err != nil {
-		return fmt.Errorf("failed to marshal completed jobs: %w", err)
+		return false, fmt.Errorf("failed to marshal completed jobs: %w", err)
 	}
 
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := db.now().Format(time.RFC3339)
 
 	// Update the enrichment
 	if allCompleted {
 		// Get current result to preserve it
 		enrichment, err := db.GetEnrichment(enrichmentID)
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		var resultJSON *string
 		if enrichment != nil && enrichment.Result != nil {
 			data, err := json.Marshal(enrichment.Result)
 			if err != nil {
-				return fmt.Errorf("failed to marshal result: %w", err)
+				return false, fmt.Errorf("failed to marshal result: %w", err)
 			}
 			s := string(data)
 			resultJSON = &s
 		}
 
+		tx, err := db.conn.Begin()
+		if err != nil {
+			return false, fmt.Errorf("failed to begin completing enrichment: %w", err)
+		}
+		defer tx.Rollback()
+
 		// When all jobs are completed, clear all provider IDs
-		_, err = db.conn.Exec(`
+		_, err = tx.Exec(`
 			UPDATE enrichments
-			SET status = ?, updated_at = ?, completed_jobs = ?, current_provider_id = ?, phone_provider_id = ?, email_provider_id = ?, result = ?
+			SET updated_at = ?, completed_jobs = ?, current_provider_id = ?, phone_provider_id = ?, email_provider_id = ?, result = ?
 			WHERE id = ?
-		`, models.EnrichmentStatusCompleted, now, string(completedJobsJSON), nil, nil, nil, resultJSON, enrichmentID)
+		`, now, string(completedJobsJSON), nil, nil, nil, resultJSON, enrichmentID)
 		if err != nil {
-			return fmt.Errorf("failed to update enrichment: %w", err)
+			return false, fmt.Errorf("failed to update enrichment: %w", err)
 		}
-	} else {
-		_, err = db.conn.Exec(`
+
+		// An enrichment that was cancelled or failed meanwhile keeps its status even if its last job finishes afterwards
+		res, err := tx.Exec(`
 			UPDATE enrichments
-			SET updated_at = ?, completed_jobs = ?
-			WHERE id = ?
-		`, now, string(completedJobsJSON), enrichmentID)
+			SET status = ?
+			WHERE id = ? AND `+notTerminal+`
+		`, models.EnrichmentStatusCompleted, enrichmentID)
 		if err != nil {
-			return fmt.Errorf("failed to update enrichment: %w", err)
+			return false, fmt.Errorf("failed to complete enrichment: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return false, fmt.Errorf("failed to check completed enrichment: %w", err)
 		}
+
+		if err := tx.Commit(); err != nil {
+			return false, fmt.Errorf("failed to commit completed enrichment: %w", err)
+		}
+
+		return affected > 0, nil
 	}
 
-	return nil
+	_, err = db.conn.Exec(`
+		UPDATE enrichments
+		SET updated_at = ?, completed_jobs = ?
+		WHERE id = ?
+	`, now, string(completedJobsJSON), enrichmentID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update enrichment: %w", err)
+	}
+
+	return false, nil
+}
+
+// EnrichmentFilter describes which enrichments ListEnrichments returns
+type EnrichmentFilter struct {
+	UserID        string
+	BatchID       string
+	Statuses      []models.EnrichmentStatus
+	ExcludeStatic bool   // skip the seeded static enrichments
+	Job           string // only enrichments that requested this job type
+	CreatedAfter  string // RFC3339, inclusive
+	CreatedBefore string // RFC3339, exclusive
+	SortBy        string // "created_at" or "updated_at"
+	Descending    bool
+	Limit         int
+	// AfterValue and AfterID continue a listing after the row with this sort value and ID
+	AfterValue string
+	AfterID    string
+}
+
+// ListEnrichments returns the enrichments matching the filter, ordered by the sort column and ID
+func (db *DB) ListEnrichments(filter EnrichmentFilter) ([]*models.Enrichment, error) {
+	sortColumn := "created_at"
+	if filter.SortBy == "updated_at" {
+		sortColumn = "updated_at"
+	}
+	direction := "ASC"
+	comparison := ">"
+	if filter.Descending {
+		direction = "DESC"
+		comparison = "<"
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	if filter.UserID != "" {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.BatchID != "" {
+		conditions = append(conditions, "batch_id = ?")
+		args = append(args, filter.BatchID)
+	}
+	if filter.ExcludeStatic {
+		conditions = append(conditions, "is_static = 0")
+	}
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = "?"
+			args = append(args, status)
+		}
+		conditions = append(conditions, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filter.Job != "" {
+		// jobs is stored as a JSON array of strings
+		conditions = append(conditions, "jobs LIKE ?")
+		args = append(args, `%"`+filter.Job+`"%`)
+	}
+	if filter.CreatedAfter != "" {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != "" {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.CreatedBefore)
+	}
+	if filter.AfterID != "" {
+		conditions = append(conditions, "("+sortColumn+", id) "+comparison+" (?, ?)")
+		args = append(args, filter.AfterValue, filter.AfterID)
+	}
+
+	query := `
+		SELECT id, user_id, status, created_at, updated_at
+		FROM enrichments`
+	if len(conditions) > 0 {
+		query += `
+		WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += `
+		ORDER BY ` + sortColumn + ` ` + direction + `, id ` + direction
+	if filter.Limit > 0 {
+		query += `
+		LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enrichments: %w", err)
+	}
+	defer rows.Close()
+
+	var enrichments []*models.Enrichment
+	for rows.Next() {
+		var e models.Enrichment
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Status, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan enrichment: %w", err)
+		}
+		enrichments = append(enrichments, &e)
+	}
+
+	return enrichments, rows.Err()
 }
 
 // GetPendingEnrichments returns enrichments that are pending and older than the given duration
 func (db *DB) GetPendingEnrichments(olderThan time.Duration) ([]*models.Enrichment, error) {
-	cutoff := time.Now().UTC().Add(-olderThan).Format(time.RFC3339)
+	cutoff := db.now().Add(-olderThan).Format(time.RFC3339)
 
 	rows, err := db.conn.Query(`
 		SELECT id, user_id, status, created_at, updated_at
@@ -439,12 +981,12 @@ func (db *DB) GetPendingEnrichments(olderThan time.Duration) ([]*models.Enrichme
 
 // GetInProgressEnrichments returns enrichments that are in_progress and older than the given duration
 func (db *DB) GetInProgressEnrichments(olderThan time.Duration) ([]*models.Enrichment, error) {
-	cutoff := time.Now().UTC().Add(-olderThan).Format(time.RFC3339)
+	cutoff := db.now().Add(-olderThan).Format(time.RFC3339)
 
 	rows, err := db.conn.Query(`
 		SELECT id, user_id, status, created_at, updated_at
 		FROM enrichments
-		WHERE status = ? AND is_static = 0 AND updated_at < ?
+		WHERE status = ? AND is_static = 0 AND updated_at <= ?
 	`, models.EnrichmentStatusInProgress, cutoff)
 
 	if err != nil {
@@ -476,8 +1018,14 @@ func (db *DB) UpdateEnrichmentStatusWithProvider(id string, status models.Enrich
 
 // UpdateEnrichmentResultField updates only a specific field (phone or email) in the result JSON
 // This ensures each job type only updates its own field without overwriting the other
+// The value is normalized like contact fields, an empty value clears the field
 func (db *DB) UpdateEnrichmentResultField(id string, fieldName string, value string) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := db.now().Format(time.RFC3339)
+
+	value, e164, err := normalizeJobValue(fieldName, value)
+	if err != nil {
+		return err
+	}
 
 	// Get current result
 	enrichment, err := db.GetEnrichment(id)
@@ -490,16 +1038,16 @@ func (db *DB) UpdateEnrichmentResultField(id string, fieldName string, value str
 	if enrichment != nil && enrichment.Result != nil {
 		// Preserve existing values
 		result.Phone = enrichment.Result.Phone
+		result.PhoneE164 = enrichment.Result.PhoneE164
 		result.Email = enrichment.Result.Email
 	}
 
 	// Update only the specified field
 	if fieldName == "phone" {
 		result.Phone = value
-	} else if fieldName == "email" {
-		result.Email = value
+		result.PhoneE164 = e164
 	} else {
-		return fmt.Errorf("invalid field name: %s (must be 'phone' or 'email')", fieldName)
+		result.Email = value
 	}
 
 	// Marshal the updated result
@@ -522,9 +1070,38 @@ func (db *DB) UpdateEnrichmentResultField(id string, fieldName string, value str
 	return nil
 }
 
+// normalizeJobValue normalizes a value found by a phone or email job like the contact fields.
+// It returns the value to store, phones as they were found, and for phones their E.164 form; an empty value stays empty.
+func normalizeJobValue(jobType, value string) (string, string, error) {
+	value = strings.TrimSpace(value)
+
+	switch jobType {
+	case string(models.JobTypePhone):
+		if value == "" {
+			return "", "", nil
+		}
+		phone, err := normalize.ParsePhone(value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid phone %q: %w", value, err)
+		}
+		return phone.Display, phone.E164, nil
+	case string(models.JobTypeEmail):
+		if value == "" {
+			return "", "", nil
+		}
+		email, err := normalize.Email(value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid email %q: %w", value, err)
+		}
+		return email, "", nil
+	default:
+		return "", "", fmt.Errorf("invalid field name: %s (must be 'phone' or 'email')", jobType)
+	}
+}
+
 // UpdateEnrichmentStatusWithJobProvider updates the status, result, and provider for a specific job type
 func (db *DB) UpdateEnrichmentStatusWithJobProvider(id string, status models.EnrichmentStatus, result *models.EnrichmentResult, providerID *string, jobType string) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := db.now().Format(time.RFC3339)
 
 	var resultJSON *string
 	if result != nil {
@@ -537,11 +1114,12 @@ func (db *DB) UpdateEnrichmentStatusWithJobProvider(id string, status models.Enr
 	}
 
 	// Update based on job type
+	// Enrichments that reached a final status (cancelled, failed or completed) are never moved into another one
 	if jobType == "phone" {
 		_, err := db.conn.Exec(`
 			UPDATE enrichments
 			SET status = ?, updated_at = ?, phone_provider_id = ?
-			WHERE id = ?
+			WHERE id = ? AND `+notTerminal+`
 		`, status, now, providerID, id)
 		if err != nil {
 			return fmt.Errorf("failed to update enrichment: %w", err)
@@ -550,7 +1128,7 @@ func (db *DB) UpdateEnrichmentStatusWithJobProvider(id string, status models.Enr
 		_, err := db.conn.Exec(`
 			UPDATE enrichments
 			SET status = ?, updated_at = ?, email_provider_id = ?
-			WHERE id = ?
+			WHERE id = ? AND `+notTerminal+`
 		`, status, now, providerID, id)
 		if err != nil {
 			return fmt.Errorf("failed to update enrichment: %w", err)
@@ -561,7 +1139,7 @@ func (db *DB) UpdateEnrichmentStatusWithJobProvider(id string, status models.Enr
 			_, err := db.conn.Exec(`
 				UPDATE enrichments
 				SET status = ?, updated_at = ?, result = ?, current_provider_id = ?, phone_provider_id = ?, email_provider_id = ?
-				WHERE id = ?
+				WHERE id = ? AND `+notTerminal+`
 			`, status, now, resultJSON, nil, nil, nil, id)
 			if err != nil {
 				return fmt.Errorf("failed to update enrichment: %w", err)
@@ -570,7 +1148,7 @@ func (db *DB) UpdateEnrichmentStatusWithJobProvider(id string, status models.Enr
 			_, err := db.conn.Exec(`
 				UPDATE enrichments
 				SET status = ?, updated_at = ?, result = ?, current_provider_id = ?
-				WHERE id = ?
+				WHERE id = ? AND `+notTerminal+`
 			`, status, now, resultJSON, providerID, id)
 			if err != nil {
 				return fmt.Errorf("failed to update enrichment: %w", err)
@@ -583,7 +1161,7 @@ func (db *DB) UpdateEnrichmentStatusWithJobProvider(id string, status models.Enr
 
 // ClearJobProvider clears the provider ID for a specific job type without changing the status
 func (db *DB) ClearJobProvider(id string, jobType string) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := db.now().Format(time.RFC3339)
 
 	if jobType == "phone" {
 		_, err := db.conn.Exec(`
@@ -608,18 +1186,368 @@ func (db *DB) ClearJobProvider(id string, jobType string) error {
 	return nil
 }
 
-// SeedStaticEnrichments creates the static test enrichments if they don't exist
-func (db *DB) SeedStaticEnrichments() error {
-	staticEnrichments := []struct {
-		ID              string
-		UserID          string
-		Status          models.EnrichmentStatus
-		Phone           string
-		Email           string
-		PhoneProviderID *string
-		EmailProviderID *string
-		CompletedJobs   []string
-	}{
+// CancelEnrichment marks a pending or in_progress enrichment as cancelled and clears its provider IDs.
+// Any result already found is left intact. Returns false if the enrichment is static or no longer running.
+func (db *DB) CancelEnrichment(id string) (bool, error) {
+	now := db.now().Format(time.RFC3339)
+
+	res, err := db.conn.Exec(`
+		UPDATE enrichments
+		SET status = ?, updated_at = ?, current_provider_id = ?, phone_provider_id = ?, email_provider_id = ?
+		WHERE id = ? AND is_static = 0 AND status IN (?, ?)
+	`, models.EnrichmentStatusCancelled, now, nil, nil, nil, id, models.EnrichmentStatusPending, models.EnrichmentStatusInProgress)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel enrichment: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check cancelled enrichment: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// FailEnrichment marks a pending or in_progress enrichment as failed, recording why
+func (db *DB) FailEnrichment(id, reason string) error {
+	now := db.now().Format(time.RFC3339)
+
+	_, err := db.conn.Exec(`
+		UPDATE enrichments
+		SET status = ?, updated_at = ?, failure_reason = ?, current_provider_id = ?, phone_provider_id = ?, email_provider_id = ?
+		WHERE id = ? AND status IN (?, ?)
+	`, models.EnrichmentStatusFailed, now, reason, nil, nil, nil, id, models.EnrichmentStatusPending, models.EnrichmentStatusInProgress)
+	if err != nil {
+		return fmt.Errorf("failed to fail enrichment: %w", err)
+	}
+
+	return nil
+}
+
+// failUserEnrichments marks every pending and in_progress enrichment of a contact as failed, recording why,
+// and returns their IDs. Static enrichments are left as they are.
+func (db *DB) failUserEnrichments(tx *sql.Tx, userID, reason string) ([]string, error) {
+	rows, err := tx.Query(`
+		SELECT id
+		FROM enrichments
+		WHERE user_id = ? AND is_static = 0 AND status IN (?, ?)
+		ORDER BY created_at ASC, id ASC
+	`, userID, models.EnrichmentStatusPending, models.EnrichmentStatusInProgress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get running enrichments: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan enrichment: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get running enrichments: %w", err)
+	}
+
+	now := db.now().Format(time.RFC3339)
+	for _, id := range ids {
+		_, err := tx.Exec(`
+			UPDATE enrichments
+			SET status = ?, updated_at = ?, failure_reason = ?, current_provider_id = ?, phone_provider_id = ?, email_provider_id = ?
+			WHERE id = ?
+		`, models.EnrichmentStatusFailed, now, reason, nil, nil, nil, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fail enrichment %s: %w", id, err)
+		}
+	}
+
+	return ids, nil
+}
+
+// IsStaticEnrichment reports whether an enrichment is one of the static seeded rows
+func (db *DB) IsStaticEnrichment(id string) (bool, error) {
+	var isStatic int
+
+	err := db.conn.QueryRow(`
+		SELECT is_static
+		FROM enrichments
+		WHERE id = ?
+	`, id).Scan(&isStatic)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get enrichment static flag: %w", err)
+	}
+
+	return isStatic == 1, nil
+}
+
+// CreateBatch creates a batch and an enrichment in it for each of the given options.
+// Everything is created in one transaction, so a failure never leaves a half-created batch.
+func (db *DB) CreateBatch(options []EnrichmentOptions) (string, []*models.Enrichment, error) {
+	id := uuid.New().String()
+	now := db.now().Format(time.RFC3339)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to begin creating batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO enrichment_batches (id, created_at)
+		VALUES (?, ?)
+	`, id, now)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	enrichments := make([]*models.Enrichment, 0, len(options))
+	for _, opts := range options {
+		opts.BatchID = id
+		enrichment, err := db.insertEnrichment(tx, opts)
+		if err != nil {
+			return "", nil, err
+		}
+		enrichments = append(enrichments, enrichment)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+
+	return id, enrichments, nil
+}
+
+// GetBatchCreatedAt returns when a batch was created, or an empty string if it does not exist
+func (db *DB) GetBatchCreatedAt(id string) (string, error) {
+	var createdAt string
+
+	err := db.conn.QueryRow(`
+		SELECT created_at
+		FROM enrichment_batches
+		WHERE id = ?
+	`, id).Scan(&createdAt)
+
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get batch: %w", err)
+	}
+
+	return createdAt, nil
+}
+
+// IdempotencyRecord is the stored outcome of a request made with an Idempotency-Key
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Response    []byte
+	CreatedAt   string
+}
+
+// GetIdempotencyKey retrieves the stored outcome for an idempotency key
+// Returns nil if the key has not been used
+func (db *DB) GetIdempotencyKey(key string) (*IdempotencyRecord, error) {
+	var record IdempotencyRecord
+	var response string
+
+	err := db.conn.QueryRow(`
+		SELECT key, request_hash, status_code, response, created_at
+		FROM idempotency_keys
+		WHERE key = ?
+	`, key).Scan(&record.Key, &record.RequestHash, &record.StatusCode, &response, &record.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	record.Response = []byte(response)
+	return &record, nil
+}
+
+// SaveIdempotencyKey stores the outcome of a request for replay
+func (db *DB) SaveIdempotencyKey(record *IdempotencyRecord) error {
+	if record.CreatedAt == "" {
+		record.CreatedAt = db.now().Format(time.RFC3339)
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO idempotency_keys (key, request_hash, status_code, response, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, record.Key, record.RequestHash, record.StatusCode, string(record.Response), record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes idempotency keys stored longer ago than the given duration
+func (db *DB) DeleteExpiredIdempotencyKeys(olderThan time.Duration) error {
+	cutoff := db.now().Add(-olderThan).Format(time.RFC3339)
+
+	_, err := db.conn.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE created_at < ?
+	`, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return nil
+}
+
+// CreateWebhookDelivery records a webhook delivery attempt
+func (db *DB) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	delivery.ID = uuid.New().String()
+	delivery.CreatedAt = db.now().Format(time.RFC3339)
+
+	var statusCode *int
+	if delivery.StatusCode != 0 {
+		statusCode = &delivery.StatusCode
+	}
+
+	_, err := db.conn.Exec(`
+		INSERT INTO webhook_deliveries (id, enrichment_id, event, url, attempt, status_code, error, success, duration_ms, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, delivery.ID, delivery.EnrichmentID, delivery.Event, delivery.URL, delivery.Attempt, statusCode, nullString(delivery.Error), delivery.Success, delivery.DurationMs, delivery.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns every delivery attempt for an enrichment, oldest first
+func (db *DB) GetWebhookDeliveries(enrichmentID string) ([]models.WebhookDelivery, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, enrichment_id, event, url, attempt, status_code, error, success, duration_ms, created_at
+		FROM webhook_deliveries
+		WHERE enrichment_id = ?
+		ORDER BY created_at, attempt
+	`, enrichmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var statusCode sql.NullInt64
+		var deliveryError sql.NullString
+		if err := rows.Scan(&d.ID, &d.EnrichmentID, &d.Event, &d.URL, &d.Attempt, &statusCode, &deliveryError, &d.Success, &d.DurationMs, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		d.StatusCode = int(statusCode.Int64)
+		d.Error = deliveryError.String
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// attemptTimeFormat is RFC3339 with a fixed number of fractional digits, so attempts sort as text
+const attemptTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// StartProviderAttempt records that a provider started looking up a job and returns the attempt ID
+func (db *DB) StartProviderAttempt(enrichmentID, job, providerID string) (string, error) {
+	id := uuid.New().String()
+	now := db.now().Format(attemptTimeFormat)
+
+	_, err := db.conn.Exec(`
+		INSERT INTO provider_attempts (id, enrichment_id, job, provider_id, outcome, started_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, id, enrichmentID, job, providerID, models.AttemptOutcomeInProgress, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to start provider attempt: %w", err)
+	}
+
+	return id, nil
+}
+
+// FinishProviderAttempt records the outcome of a provider attempt
+func (db *DB) FinishProviderAttempt(id string, outcome models.AttemptOutcome, attemptError string) error {
+	var startedAt string
+	if err := db.conn.QueryRow(`SELECT started_at FROM provider_attempts WHERE id = ?`, id).Scan(&startedAt); err != nil {
+		return fmt.Errorf("failed to get provider attempt: %w", err)
+	}
+
+	now := db.now()
+	var durationMs int64
+	if started, err := time.Parse(attemptTimeFormat, startedAt); err == nil {
+		durationMs = now.Sub(started).Milliseconds()
+	}
+
+	_, err := db.conn.Exec(`
+		UPDATE provider_attempts
+		SET outcome = ?, error = ?, finished_at = ?, duration_ms = ?
+		WHERE id = ?
+	`, outcome, nullString(attemptError), now.Format(attemptTimeFormat), durationMs, id)
+	if err != nil {
+		return fmt.Errorf("failed to finish provider attempt: %w", err)
+	}
+
+	return nil
+}
+
+// GetProviderAttempts returns every provider attempt of an enrichment, oldest first
+func (db *DB) GetProviderAttempts(enrichmentID string) ([]models.ProviderAttempt, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, job, provider_id, outcome, error, started_at, finished_at, duration_ms
+		FROM provider_attempts
+		WHERE enrichment_id = ?
+		ORDER BY started_at, id
+	`, enrichmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query provider attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []models.ProviderAttempt{}
+	for rows.Next() {
+		var a models.ProviderAttempt
+		var attemptError sql.NullString
+		var finishedAt sql.NullString
+		var durationMs sql.NullInt64
+		if err := rows.Scan(&a.ID, &a.Job, &a.ProviderID, &a.Outcome, &attemptError, &a.StartedAt, &finishedAt, &durationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan provider attempt: %w", err)
+		}
+		a.Error = attemptError.String
+		a.FinishedAt = finishedAt.String
+		a.DurationMs = durationMs.Int64
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
+
+// StaticEnrichment is an enrichment seeded on startup and on every reset, frozen in its state
+type StaticEnrichment struct {
+	ID              string                  `json:"id"`
+	UserID          string                  `json:"userId"`
+	Status          models.EnrichmentStatus `json:"status"`
+	Phone           string                  `json:"phone,omitempty"`
+	Email           string                  `json:"email,omitempty"`
+	PhoneProviderID *string                 `json:"phoneProviderId,omitempty"`
+	EmailProviderID *string                 `json:"emailProviderId,omitempty"`
+	// Jobs defaults to both phone and email
+	Jobs          []string `json:"jobs,omitempty"`
+	CompletedJobs []string `json:"completedJobs,omitempty"`
+}
+
+// DefaultStaticEnrichments returns the built-in static enrichments, one per status
+func DefaultStaticEnrichments() []StaticEnrichment {
+	return []StaticEnrichment{
 		{
 			ID:            "e5f6a7b8-c9d0-1234-ef12-345678901234",
 			UserID:        "a1b2c3d4-e5f6-7890-abcd-ef1234567890", // John Doe
@@ -649,13 +1577,43 @@ func (db *DB) SeedStaticEnrichments() error {
 			CompletedJobs: []string{},
 		},
 	}
+}
 
-	now := time.Now().UTC().Format(time.RFC3339)
+// SetStaticEnrichments replaces the built-in static enrichments seeded by SeedStaticEnrichments
+func (db *DB) SetStaticEnrichments(enrichments []StaticEnrichment) {
+	db.staticEnrichments = enrichments
+}
+
+// SeedStaticEnrichments creates the static test enrichments if they don't exist
+func (db *DB) SeedStaticEnrichments() error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin seeding static enrichments: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := db.insertStaticEnrichments(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit static enrichments: %w", err)
+	}
+	return nil
+}
+
+// insertStaticEnrichments creates the static test enrichments that don't exist yet within tx
+func (db *DB) insertStaticEnrichments(tx *sql.Tx) error {
+	staticEnrichments := db.staticEnrichments
+	if staticEnrichments == nil {
+		staticEnrichments = DefaultStaticEnrichments()
+	}
+
+	now := db.now().Format(time.RFC3339)
 
 	for _, e := range staticEnrichments {
 		// Check if already exists
 		var count int
-		err := db.conn.QueryRow("SELECT COUNT(*) FROM enrichments WHERE id = ?", e.ID).Scan(&count)
+		err := tx.QueryRow("SELECT COUNT(*) FROM enrichments WHERE id = ?", e.ID).Scan(&count)
 		if err != nil {
 			return fmt.Errorf("failed to check existing enrichment: %w", err)
 		}
@@ -667,9 +1625,18 @@ func (db *DB) SeedStaticEnrichments() error {
 		// Build result JSON only if phone or email is set
 		var resultJSON *string
 		if e.Phone != "" || e.Email != "" {
+			phone, phoneE164, err := normalizeJobValue(string(models.JobTypePhone), e.Phone)
+			if err != nil {
+				return fmt.Errorf("failed to seed enrichment %s: %w", e.ID, err)
+			}
+			email, _, err := normalizeJobValue(string(models.JobTypeEmail), e.Email)
+			if err != nil {
+				return fmt.Errorf("failed to seed enrichment %s: %w", e.ID, err)
+			}
 			result := models.EnrichmentResult{
-				Phone: e.Phone,
-				Email: e.Email,
+				Phone:     phone,
+				PhoneE164: phoneE164,
+				Email:     email,
 			}
 			data, err := json.Marshal(result)
 			if err != nil {
@@ -680,18 +1647,29 @@ func (db *DB) SeedStaticEnrichments() error {
 		}
 
 		// Marshal completed jobs
-		completedJobsJSON, err := json.Marshal(e.CompletedJobs)
+		completedJobs := e.CompletedJobs
+		if completedJobs == nil {
+			completedJobs = []string{}
+		}
+		completedJobsJSON, err := json.Marshal(completedJobs)
 		if err != nil {
 			return fmt.Errorf("failed to marshal completed jobs: %w", err)
 		}
 
 		// Default to both phone and email jobs for static enrichments
-		jobsJSON := `["phone", "email"]`
+		jobs := e.Jobs
+		if len(jobs) == 0 {
+			jobs = []string{"phone", "email"}
+		}
+		jobsJSON, err := json.Marshal(jobs)
+		if err != nil {
+			return fmt.Errorf("failed to marshal jobs: %w", err)
+		}
 
-		_, err = db.conn.Exec(`
+		_, err = tx.Exec(`
 			INSERT INTO enrichments (id, user_id, status, created_at, updated_at, result, current_provider_id, phone_provider_id, email_provider_id, jobs, completed_jobs, is_static)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
-		`, e.ID, e.UserID, e.Status, now, now, resultJSON, nil, e.PhoneProviderID, e.EmailProviderID, jobsJSON, string(completedJobsJSON))
+		`, e.ID, e.UserID, e.Status, now, now, resultJSON, nil, e.PhoneProviderID, e.EmailProviderID, string(jobsJSON), string(completedJobsJSON))
 
 		if err != nil {
 			return fmt.Errorf("failed to seed enrichment %s: %w", e.ID, err)
@@ -703,7 +1681,20 @@ func (db *DB) SeedStaticEnrichments() error {
 	return nil
 }
 
+// now returns the current time of the database clock in UTC
+func (db *DB) now() time.Time {
+	return db.clock.Now().UTC()
+}
+
 // strPtr is a helper function to create a pointer to a string
 func strPtr(s string) *string {
 	return &s
 }
+
+// nullString returns nil for an empty string so it is stored as NULL
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
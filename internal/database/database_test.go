@@ -0,0 +1,106 @@
+package database
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/surfe/mock-api/internal/clock"
+	"github.com/surfe/mock-api/internal/models"
+)
+
+// newTestDB creates an empty database of its own, on a paused virtual clock
+func newTestDB(t *testing.T) (*DB, *clock.Virtual) {
+	t.Helper()
+
+	clk := clock.NewVirtual(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), 0)
+	db, err := New(filepath.Join(t.TempDir(), "test.db"), clk)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, clk
+}
+
+func TestListEnrichmentsKeyset(t *testing.T) {
+	db, clk := newTestDB(t)
+
+	// Several enrichments share each second, so the ID has to break the ties
+	var created []*models.Enrichment
+	for _, n := range []int{3, 2, 1} {
+		for i := 0; i < n; i++ {
+			enrichment, err := db.CreateEnrichmentWithOptions(EnrichmentOptions{UserID: "user-1"})
+			if err != nil {
+				t.Fatalf("CreateEnrichmentWithOptions: %v", err)
+			}
+			created = append(created, enrichment)
+		}
+		clk.Advance(time.Second)
+	}
+
+	tests := []struct {
+		name       string
+		descending bool
+		limit      int
+	}{
+		{name: "ascending by 1", limit: 1},
+		{name: "ascending by 2", limit: 2},
+		{name: "ascending by 4", limit: 4},
+		{name: "descending by 2", descending: true, limit: 2},
+		{name: "descending by 5", descending: true, limit: 5},
+		{name: "single page", limit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := make([]string, len(created))
+			sorted := append([]*models.Enrichment(nil), created...)
+			sort.Slice(sorted, func(i, j int) bool {
+				a, b := sorted[i], sorted[j]
+				if tt.descending {
+					a, b = b, a
+				}
+				if a.CreatedAt != b.CreatedAt {
+					return a.CreatedAt < b.CreatedAt
+				}
+				return a.ID < b.ID
+			})
+			for i, e := range sorted {
+				want[i] = e.ID
+			}
+
+			filter := EnrichmentFilter{UserID: "user-1", SortBy: "created_at", Descending: tt.descending, Limit: tt.limit}
+			var got []string
+			for pages := 0; ; pages++ {
+				if pages > len(created) {
+					t.Fatalf("listing did not end, got %v", got)
+				}
+				rows, err := db.ListEnrichments(filter)
+				if err != nil {
+					t.Fatalf("ListEnrichments: %v", err)
+				}
+				if len(rows) > tt.limit {
+					t.Fatalf("got %d rows, want at most %d", len(rows), tt.limit)
+				}
+				for _, row := range rows {
+					got = append(got, row.ID)
+				}
+				if len(rows) < tt.limit {
+					break
+				}
+				last := rows[len(rows)-1]
+				filter.AfterValue, filter.AfterID = last.CreatedAt, last.ID
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("got %d enrichments, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("enrichment %d = %s, want %s\ngot  %v\nwant %v", i, got[i], want[i], got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/surfe/mock-api/internal/models"
+)
+
+// stateTables lists every table holding state, in the order they are restored
+var stateTables = []string{
+	"contacts",
+	"enrichment_batches",
+	"enrichments",
+	"provider_attempts",
+	"webhook_deliveries",
+	"idempotency_keys",
+}
+
+// Snapshot is a copy of every row of the state tables
+type Snapshot struct {
+	tables map[string]*tableRows
+}
+
+// tableRows holds the rows of a table
+type tableRows struct {
+	columns []string
+	rows    [][]any
+}
+
+// Contacts returns how many contacts the snapshot holds
+func (s *Snapshot) Contacts() int {
+	return s.count("contacts")
+}
+
+// Enrichments returns how many enrichments the snapshot holds
+func (s *Snapshot) Enrichments() int {
+	return s.count("enrichments")
+}
+
+// count returns how many rows of a table the snapshot holds
+func (s *Snapshot) count(table string) int {
+	if t, ok := s.tables[table]; ok {
+		return len(t.rows)
+	}
+	return 0
+}
+
+// Snapshot copies every row of the state tables
+func (db *DB) Snapshot() (*Snapshot, error) {
+	// A single transaction gives a consistent view across the tables
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin snapshot: %w", err)
+	}
+	defer tx.Rollback()
+
+	snapshot := &Snapshot{tables: make(map[string]*tableRows, len(stateTables))}
+	for _, table := range stateTables {
+		rows, err := copyTable(tx, table)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.tables[table] = rows
+	}
+
+	return snapshot, nil
+}
+
+// Restore replaces the state tables with the rows of a snapshot.
+// Provider attempts that were in flight when the snapshot was taken are marked cancelled,
+// since the lookups behind them are gone.
+func (db *DB) Restore(snapshot *Snapshot) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin restore: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := clearTables(tx); err != nil {
+		return err
+	}
+
+	for _, table := range stateTables {
+		t, ok := snapshot.tables[table]
+		if !ok || len(t.rows) == 0 {
+			continue
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(t.columns)), ", ")
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(t.columns, ", "), placeholders)
+		for _, row := range t.rows {
+			if _, err := tx.Exec(query, row...); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", table, err)
+			}
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE provider_attempts
+		SET outcome = ?, finished_at = ?
+		WHERE outcome = ?
+	`, models.AttemptOutcomeCancelled, db.now().Format(attemptTimeFormat), models.AttemptOutcomeInProgress)
+	if err != nil {
+		return fmt.Errorf("failed to close interrupted provider attempts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit restore: %w", err)
+	}
+
+	return nil
+}
+
+// Reset deletes every contact, enrichment, batch, attempt, delivery and idempotency key
+// and seeds the contacts and static enrichments again, all in one transaction so a failed
+// reset leaves the state as it was
+func (db *DB) Reset() error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin reset: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := clearTables(tx); err != nil {
+		return err
+	}
+	if err := db.insertSeedContacts(tx); err != nil {
+		return err
+	}
+	if err := db.insertStaticEnrichments(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reset: %w", err)
+	}
+
+	return nil
+}
+
+// copyTable reads every row of a table
+func copyTable(tx *sql.Tx, table string) (*tableRows, error) {
+	rows, err := tx.Query("SELECT * FROM " + table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s columns: %w", table, err)
+	}
+
+	t := &tableRows{columns: columns}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		t.rows = append(t.rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+
+	return t, nil
+}
+
+// clearTables deletes every row of the state tables
+func clearTables(tx *sql.Tx) error {
+	for i := len(stateTables) - 1; i >= 0; i-- {
+		if _, err := tx.Exec("DELETE FROM " + stateTables[i]); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", stateTables[i], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/surfe/mock-api/internal/models"
+)
+
+func TestResetIsAtomic(t *testing.T) {
+	db, _ := newTestDB(t)
+
+	db.SetSeedContacts([]models.Contact{{ID: "seed-1", FirstName: "Jane", LastName: "Doe"}})
+	if err := db.Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if _, err := db.CreateContact(models.Contact{ID: "added", FirstName: "John", LastName: "Doe"}); err != nil {
+		t.Fatalf("CreateContact: %v", err)
+	}
+
+	// A seed contact that cannot be stored fails the reset, which must leave the state as it was
+	db.SetSeedContacts([]models.Contact{
+		{ID: "seed-2", FirstName: "Ann", LastName: "Smith"},
+		{ID: "seed-3", FirstName: "Bob", LastName: "Adams", Phone: "not a phone"},
+	})
+	if err := db.Reset(); err == nil {
+		t.Fatal("Reset with an invalid seed contact succeeded")
+	}
+
+	for id, want := range map[string]bool{"seed-1": true, "added": true, "seed-2": false} {
+		contact, err := db.GetContact(id)
+		if err != nil {
+			t.Fatalf("GetContact(%s): %v", id, err)
+		}
+		if got := contact != nil; got != want {
+			t.Errorf("contact %s exists = %v, want %v", id, got, want)
+		}
+	}
+	if e, err := db.GetEnrichment(DefaultStaticEnrichments()[0].ID); err != nil || e == nil {
+		t.Errorf("static enrichment is gone after the failed reset: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/surfe/mock-api/internal/clock"
+	"github.com/surfe/mock-api/internal/models"
+)
+
+// Type identifies what happened to an enrichment
+type Type string
+
+const (
+	TypeProviderChanged Type = "provider_changed"
+	TypeValueFound      Type = "value_found"
+	TypeJobCompleted    Type = "job_completed"
+	TypeStatusChanged   Type = "status_changed"
+)
+
+const (
+	// DefaultHistorySize is how many past events are kept for Last-Event-ID resume
+	DefaultHistorySize = 1000
+
+	// subscriptionBuffer is how many events a subscriber can fall behind before events are dropped
+	subscriptionBuffer = 64
+)
+
+// Event describes a change to an enrichment
+type Event struct {
+	ID           int64                   `json:"id"`
+	Type         Type                    `json:"type"`
+	EnrichmentID string                  `json:"enrichmentId"`
+	UserID       string                  `json:"userId,omitempty"`
+	Job          string                  `json:"job,omitempty"`
+	Provider     *models.Provider        `json:"provider,omitempty"`
+	Value        string                  `json:"value,omitempty"`
+	Status       models.EnrichmentStatus `json:"status,omitempty"`
+	Time         string                  `json:"time"`
+}
+
+// Terminal reports whether the event moves the enrichment into a final status
+func (e Event) Terminal() bool {
+	return e.Type == TypeStatusChanged && e.Status.IsTerminal()
+}
+
+// Broker is an in-process publish/subscribe hub for enrichment events.
+// The worker publishes to it and HTTP handlers subscribe to it.
+type Broker struct {
+	mu          sync.Mutex
+	nextID      int64
+	subscribers map[*Subscription]struct{}
+	history     []Event
+	historySize int
+	// trimmedID is the ID of the newest event dropped from the history
+	trimmedID int64
+	clock     clock.Clock
+}
+
+// Subscription receives every event published after it was created
+type Subscription struct {
+	// C delivers the events. It is closed when the subscription is closed.
+	C <-chan Event
+
+	// Lagged receives a value when events were dropped because C was full, so the
+	// subscriber can catch up from the history
+	Lagged <-chan struct{}
+
+	ch     chan Event
+	lagged chan struct{}
+	broker *Broker
+}
+
+// NewBroker creates a broker that keeps the last historySize events for replay and timestamps them with clk
+func NewBroker(historySize int, clk clock.Clock) *Broker {
+	return &Broker{
+		subscribers: make(map[*Subscription]struct{}),
+		historySize: historySize,
+		clock:       clk,
+	}
+}
+
+// Publish assigns the event an ID and timestamp and delivers it to all subscribers.
+// Subscribers that are too far behind miss the event instead of blocking the publisher, and are told on Lagged.
+func (b *Broker) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	e.ID = b.nextID
+	e.Time = b.clock.Now().UTC().Format(time.RFC3339Nano)
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.trimmedID = b.history[len(b.history)-b.historySize-1].ID
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- e:
+		default:
+			select {
+			case sub.lagged <- struct{}{}:
+			default:
+			}
+		}
+	}
+
+	return e
+}
+
+// Subscribe registers a new subscription. Close it when done.
+func (b *Broker) Subscribe() *Subscription {
+	ch := make(chan Event, subscriptionBuffer)
+	lagged := make(chan struct{}, 1)
+	sub := &Subscription{C: ch, Lagged: lagged, ch: ch, lagged: lagged, broker: b}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// LastID returns the ID of the latest published event, 0 if there is none
+func (b *Broker) LastID() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextID
+}
+
+// Since returns the retained events for an enrichment with an ID greater than lastID.
+// complete is false when some of those events were already dropped from the history, or lastID
+// was never published, in which case the events cannot be relied on to catch up.
+func (b *Broker) Since(enrichmentID string, lastID int64) (events []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, e := range b.history {
+		if e.ID > lastID && e.EnrichmentID == enrichmentID {
+			events = append(events, e)
+		}
+	}
+	return events, lastID >= b.trimmedID && lastID <= b.nextID
+}
+
+// Close unregisters the subscription and closes its channel
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if _, ok := s.broker.subscribers[s]; ok {
+		delete(s.broker.subscribers, s)
+		close(s.ch)
+	}
+}
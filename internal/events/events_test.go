@@ -0,0 +1,66 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/surfe/mock-api/internal/clock"
+)
+
+func TestSubscriptionLagged(t *testing.T) {
+	b := NewBroker(DefaultHistorySize, clock.NewVirtual(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), 0))
+	sub := b.Subscribe()
+	defer sub.Close()
+
+	for i := 0; i < subscriptionBuffer; i++ {
+		b.Publish(Event{Type: TypeProviderChanged, EnrichmentID: "other"})
+	}
+	select {
+	case <-sub.Lagged:
+		t.Fatal("Lagged signalled before any event was dropped")
+	default:
+	}
+
+	// The terminal event does not fit, the subscriber has to catch up from the history
+	last := b.Publish(Event{Type: TypeStatusChanged, EnrichmentID: "mine", Status: "completed"})
+	select {
+	case <-sub.Lagged:
+	default:
+		t.Fatal("Lagged not signalled after an event was dropped")
+	}
+	if got := b.LastID(); got != last.ID {
+		t.Errorf("LastID() = %d, want %d", got, last.ID)
+	}
+
+	missed, complete := b.Since("mine", 1)
+	if !complete || len(missed) != 1 || missed[0].ID != last.ID || !missed[0].Terminal() {
+		t.Errorf("Since() = %+v, %v, want the terminal event", missed, complete)
+	}
+}
+
+func TestSinceComplete(t *testing.T) {
+	b := NewBroker(3, clock.Real{})
+	for i := 0; i < 5; i++ {
+		b.Publish(Event{Type: TypeProviderChanged, EnrichmentID: "e"})
+	}
+
+	// Events 1 and 2 were trimmed from the history, 3 to 5 are kept
+	tests := []struct {
+		lastID   int64
+		missed   int
+		complete bool
+	}{
+		{lastID: 1, missed: 3, complete: false},
+		{lastID: 2, missed: 3, complete: true},
+		{lastID: 4, missed: 1, complete: true},
+		{lastID: 5, missed: 0, complete: true},
+		{lastID: 6, missed: 0, complete: false},
+	}
+
+	for _, tt := range tests {
+		missed, complete := b.Since("e", tt.lastID)
+		if len(missed) != tt.missed || complete != tt.complete {
+			t.Errorf("Since(%d) = %d events, %v, want %d, %v", tt.lastID, len(missed), complete, tt.missed, tt.complete)
+		}
+	}
+}
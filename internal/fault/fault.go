@@ -0,0 +1,169 @@
+package fault
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/rng"
+)
+
+// Header forces faults onto a single request, e.g. "latency=2000,error=503", regardless of the rules
+const Header = "X-Mock-Fault"
+
+// Injector decides which faults are injected into a request
+type Injector struct {
+	mu    sync.RWMutex
+	rules []models.FaultRule
+	rand  *rng.Source
+}
+
+// New creates an injector without rules that rolls its dice with the given source
+func New(rand *rng.Source) *Injector {
+	if rand == nil {
+		rand = rng.New(time.Now().UnixNano())
+	}
+	return &Injector{rules: []models.FaultRule{}, rand: rand}
+}
+
+// Rules returns the current rules
+func (in *Injector) Rules() []models.FaultRule {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	rules := make([]models.FaultRule, len(in.rules))
+	copy(rules, in.rules)
+	return rules
+}
+
+// SetRules validates and replaces all rules
+func (in *Injector) SetRules(rules []models.FaultRule) error {
+	for i, rule := range rules {
+		if err := Validate(rule); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.rules = append([]models.FaultRule{}, rules...)
+	return nil
+}
+
+// LoadRules reads fault rules from a JSON file holding an array of rules
+func LoadRules(path string) ([]models.FaultRule, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fault config: %w", err)
+	}
+
+	var rules []models.FaultRule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse fault config: %w", err)
+	}
+	return rules, nil
+}
+
+// Pick returns the faults to inject into a request: those named by its X-Mock-Fault header if set,
+// otherwise every matching rule whose dice come up
+func (in *Injector) Pick(r *http.Request) ([]models.FaultRule, error) {
+	if value := r.Header.Get(Header); value != "" {
+		return ParseHeader(value)
+	}
+
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	var picked []models.FaultRule
+	for _, rule := range in.rules {
+		if !matches(rule, r) {
+			continue
+		}
+		if rule.Probability != nil && float64(in.rand.Float32()) >= *rule.Probability {
+			continue
+		}
+		picked = append(picked, rule)
+	}
+	return picked, nil
+}
+
+// Validate checks that a rule can be applied
+func Validate(rule models.FaultRule) error {
+	switch rule.Type {
+	case models.FaultTypeLatency:
+		if rule.LatencyMs <= 0 {
+			return fmt.Errorf("latencyMs must be positive for a '%s' fault", rule.Type)
+		}
+	case models.FaultTypeError:
+		if rule.Status != 0 && (rule.Status < 400 || rule.Status > 599) {
+			return fmt.Errorf("status must be between 400 and 599")
+		}
+	case models.FaultTypeTimeout, models.FaultTypeDrop, models.FaultTypeTruncate:
+	default:
+		return fmt.Errorf("type must be '%s', '%s', '%s', '%s' or '%s'",
+			models.FaultTypeLatency, models.FaultTypeError, models.FaultTypeTimeout, models.FaultTypeDrop, models.FaultTypeTruncate)
+	}
+
+	if rule.LatencyMs != 0 && rule.Type != models.FaultTypeLatency {
+		return fmt.Errorf("latencyMs is only allowed for a '%s' fault", models.FaultTypeLatency)
+	}
+	if rule.Status != 0 && rule.Type != models.FaultTypeError {
+		return fmt.Errorf("status is only allowed for an '%s' fault", models.FaultTypeError)
+	}
+	if rule.Probability != nil && (*rule.Probability < 0 || *rule.Probability > 1) {
+		return fmt.Errorf("probability must be between 0.0 and 1.0")
+	}
+	if _, err := path.Match(rule.Path, "/"); err != nil {
+		return fmt.Errorf("path is not a valid pattern: %w", err)
+	}
+	return nil
+}
+
+// ParseHeader reads the faults of an X-Mock-Fault header: a comma-separated list of
+// "latency=<ms>", "error" or "error=<status>", "timeout", "drop" and "truncate"
+func ParseHeader(value string) ([]models.FaultRule, error) {
+	var rules []models.FaultRule
+	for _, part := range strings.Split(value, ",") {
+		name, arg, hasArg := strings.Cut(strings.TrimSpace(part), "=")
+		rule := models.FaultRule{Type: models.FaultType(strings.ToLower(name))}
+
+		if hasArg {
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %q is not a number", Header, arg)
+			}
+			switch rule.Type {
+			case models.FaultTypeLatency:
+				rule.LatencyMs = n
+			case models.FaultTypeError:
+				rule.Status = n
+			default:
+				return nil, fmt.Errorf("%s: %s takes no value", Header, name)
+			}
+		}
+
+		if err := Validate(rule); err != nil {
+			return nil, fmt.Errorf("%s: %w", Header, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// matches reports whether a rule applies to a request.
+// The path is a path.Match pattern, so "/enrichment/*" matches "/enrichment/123" but not "/enrichment/123/retry".
+func matches(rule models.FaultRule, r *http.Request) bool {
+	if rule.Method != "" && !strings.EqualFold(rule.Method, r.Method) {
+		return false
+	}
+	if rule.Path == "" {
+		return true
+	}
+	ok, _ := path.Match(rule.Path, r.URL.Path)
+	return ok
+}
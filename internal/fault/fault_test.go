@@ -0,0 +1,63 @@
+package fault
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/surfe/mock-api/internal/models"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    []models.FaultRule
+		wantErr bool
+	}{
+		{header: "error", want: []models.FaultRule{{Type: models.FaultTypeError}}},
+		{header: "error=503", want: []models.FaultRule{{Type: models.FaultTypeError, Status: 503}}},
+		{header: "ERROR=429", want: []models.FaultRule{{Type: models.FaultTypeError, Status: 429}}},
+		{header: "latency=250", want: []models.FaultRule{{Type: models.FaultTypeLatency, LatencyMs: 250}}},
+		{header: "timeout", want: []models.FaultRule{{Type: models.FaultTypeTimeout}}},
+		{header: "drop", want: []models.FaultRule{{Type: models.FaultTypeDrop}}},
+		{header: "truncate", want: []models.FaultRule{{Type: models.FaultTypeTruncate}}},
+		{
+			header: " latency=100 , error=500,truncate ",
+			want: []models.FaultRule{
+				{Type: models.FaultTypeLatency, LatencyMs: 100},
+				{Type: models.FaultTypeError, Status: 500},
+				{Type: models.FaultTypeTruncate},
+			},
+		},
+		{header: "", wantErr: true},
+		{header: "error,", wantErr: true},
+		{header: "explode", wantErr: true},
+		{header: "latency", wantErr: true},
+		{header: "latency=0", wantErr: true},
+		{header: "latency=-5", wantErr: true},
+		{header: "latency=fast", wantErr: true},
+		{header: "error=200", wantErr: true},
+		{header: "error=600", wantErr: true},
+		{header: "error=5xx", wantErr: true},
+		{header: "timeout=30", wantErr: true},
+		{header: "drop=1", wantErr: true},
+		{header: "latency=100,explode", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, err := ParseHeader(tt.header)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseHeader(%q) = %+v, want an error", tt.header, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseHeader(%q) error = %v", tt.header, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseHeader(%q) = %+v, want %+v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
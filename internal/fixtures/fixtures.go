@@ -0,0 +1,303 @@
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/surfe/mock-api/internal/data"
+	"github.com/surfe/mock-api/internal/database"
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/normalize"
+	"github.com/surfe/mock-api/internal/provider"
+)
+
+// Set is the data loaded from fixture files. A section left out keeps the built-in data,
+// an empty one removes it.
+type Set struct {
+	data.Fixtures
+	Providers         []models.ProviderConfig
+	StaticEnrichments []database.StaticEnrichment
+}
+
+// file is the content of a single fixture file
+type file struct {
+	Contacts          []models.Contact            `json:"contacts"`
+	ThirdParty        []models.ThirdPartyInfo     `json:"thirdParty"`
+	EnrichmentData    []data.EnrichmentData       `json:"enrichmentData"`
+	Providers         []json.RawMessage           `json:"providers"`
+	StaticEnrichments []database.StaticEnrichment `json:"staticEnrichments"`
+}
+
+// Load reads fixtures from a JSON or YAML file, or from every *.json, *.yaml and *.yml file
+// of a directory, merging their sections. The result is validated against the built-in data
+// for the sections it leaves out.
+func Load(path string) (*Set, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		paths = nil
+		for _, pattern := range []string{"*.json", "*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list fixtures: %w", err)
+			}
+			paths = append(paths, matches...)
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no *.json, *.yaml or *.yml fixture files in %s", path)
+		}
+		sort.Strings(paths)
+	}
+
+	set := &Set{}
+	var providerEntries []json.RawMessage
+	for _, p := range paths {
+		f, err := readFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(p), err)
+		}
+		for i, contact := range f.Contacts {
+			if err := validateContact(contact); err != nil {
+				return nil, fmt.Errorf("%s: contacts[%d]: %w", filepath.Base(p), i, err)
+			}
+		}
+
+		merge(&set.Contacts, f.Contacts)
+		merge(&set.ThirdParty, f.ThirdParty)
+		merge(&set.EnrichmentData, f.EnrichmentData)
+		merge(&providerEntries, f.Providers)
+		merge(&set.StaticEnrichments, f.StaticEnrichments)
+	}
+
+	if providerEntries != nil {
+		set.Providers, err = provider.ParseConfigs(providerEntries)
+		if err != nil {
+			return nil, fmt.Errorf("providers: %w", err)
+		}
+	}
+
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// Apply replaces the built-in data with the sections of the set
+func (s *Set) Apply(md *data.MockData, db *database.DB) {
+	md.UseFixtures(s.Fixtures)
+	if s.Providers != nil {
+		md.SetProviderConfigs(s.Providers)
+	}
+	if s.StaticEnrichments != nil {
+		db.SetStaticEnrichments(s.StaticEnrichments)
+	}
+}
+
+// Validate checks every entry of the set and that they only refer to contacts and providers
+// that exist, either in the set or in the built-in data
+func (s *Set) Validate() error {
+	builtin := data.NewMockData()
+
+	contacts := s.Contacts
+	if contacts == nil {
+		contacts = builtin.GetAllContacts()
+	}
+	contactIDs := make(map[string]bool, len(contacts))
+	for i, contact := range s.Contacts {
+		if err := validateContact(contact); err != nil {
+			return fmt.Errorf("contacts[%d]: %w", i, err)
+		}
+		if contactIDs[contact.ID] {
+			return fmt.Errorf("contacts[%d]: contact %s is defined twice", i, contact.ID)
+		}
+		contactIDs[contact.ID] = true
+	}
+	for _, contact := range contacts {
+		contactIDs[contact.ID] = true
+	}
+
+	fullNames := make(map[string]bool, len(s.ThirdParty))
+	for i, info := range s.ThirdParty {
+		if info.FullName == "" {
+			return fmt.Errorf("thirdParty[%d]: fullName is required", i)
+		}
+		key := strings.ToLower(info.FullName)
+		if fullNames[key] {
+			return fmt.Errorf("thirdParty[%d]: %s is defined twice", i, info.FullName)
+		}
+		fullNames[key] = true
+	}
+
+	enrichmentData := make(map[string]bool, len(s.EnrichmentData))
+	for i, entry := range s.EnrichmentData {
+		if !contactIDs[entry.ContactID] {
+			return fmt.Errorf("enrichmentData[%d]: unknown contact %q", i, entry.ContactID)
+		}
+		if enrichmentData[entry.ContactID] {
+			return fmt.Errorf("enrichmentData[%d]: contact %s is defined twice", i, entry.ContactID)
+		}
+		if err := validatePhoneAndEmail(entry.Phone, entry.Email); err != nil {
+			return fmt.Errorf("enrichmentData[%d]: %w", i, err)
+		}
+		enrichmentData[entry.ContactID] = true
+	}
+
+	providerIDs := make(map[string]bool)
+	if s.Providers != nil {
+		for _, config := range s.Providers {
+			providerIDs[config.ID] = true
+		}
+	} else {
+		for _, p := range builtin.GetAllProviders() {
+			providerIDs[p.ID] = true
+		}
+	}
+
+	// The built-in static enrichments must still find their contacts and providers
+	if s.StaticEnrichments == nil {
+		for _, e := range database.DefaultStaticEnrichments() {
+			if err := validateStaticEnrichment(e, contactIDs, providerIDs); err != nil {
+				return fmt.Errorf("built-in static enrichment %s: %w; define staticEnrichments, an empty list for none", e.ID, err)
+			}
+		}
+	}
+	staticIDs := make(map[string]bool, len(s.StaticEnrichments))
+	for i, e := range s.StaticEnrichments {
+		if err := validateStaticEnrichment(e, contactIDs, providerIDs); err != nil {
+			return fmt.Errorf("staticEnrichments[%d]: %w", i, err)
+		}
+		if staticIDs[e.ID] {
+			return fmt.Errorf("staticEnrichments[%d]: enrichment %s is defined twice", i, e.ID)
+		}
+		staticIDs[e.ID] = true
+	}
+
+	return nil
+}
+
+// readFile decodes a fixture file, converting YAML to JSON so both formats share the JSON field names
+func readFile(path string) (*file, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc any
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse fixtures: %w", err)
+		}
+		raw, err = json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fixtures: %w", err)
+		}
+	}
+
+	// Unknown fields are most likely typos, which would otherwise silently fall back to defaults
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var f file
+	if err := decoder.Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures: %w", err)
+	}
+
+	return &f, nil
+}
+
+// merge appends the entries of a section, keeping a section given as an empty list distinct from a missing one
+func merge[T any](dst *[]T, src []T) {
+	if src == nil {
+		return
+	}
+	if *dst == nil {
+		*dst = []T{}
+	}
+	*dst = append(*dst, src...)
+}
+
+// validateContact checks a single contact
+func validateContact(contact models.Contact) error {
+	if _, err := uuid.Parse(contact.ID); err != nil {
+		return fmt.Errorf("id must be a UUID, got %q", contact.ID)
+	}
+	if _, errs := normalize.Contact(contact); len(errs) > 0 {
+		return errors.New(errs[0].Message)
+	}
+	return nil
+}
+
+// validatePhoneAndEmail checks a phone and email that end up on a contact, either of which may be empty
+func validatePhoneAndEmail(phone, email string) error {
+	if phone != "" {
+		if _, err := normalize.ParsePhone(phone); err != nil {
+			return fmt.Errorf("phone %w", err)
+		}
+	}
+	if email != "" {
+		if _, err := normalize.Email(email); err != nil {
+			return fmt.Errorf("email %w", err)
+		}
+	}
+	return nil
+}
+
+// validateStaticEnrichment checks a single static enrichment and what it refers to
+func validateStaticEnrichment(e database.StaticEnrichment, contactIDs, providerIDs map[string]bool) error {
+	if _, err := uuid.Parse(e.ID); err != nil {
+		return fmt.Errorf("id must be a UUID, got %q", e.ID)
+	}
+	if !contactIDs[e.UserID] {
+		return fmt.Errorf("unknown contact %q", e.UserID)
+	}
+
+	switch e.Status {
+	case models.EnrichmentStatusPending, models.EnrichmentStatusInProgress, models.EnrichmentStatusCompleted,
+		models.EnrichmentStatusFailed, models.EnrichmentStatusCancelled:
+	default:
+		return fmt.Errorf("status must be 'pending', 'in_progress', 'completed', 'failed' or 'cancelled'")
+	}
+
+	if err := validatePhoneAndEmail(e.Phone, e.Email); err != nil {
+		return err
+	}
+
+	for _, id := range []*string{e.PhoneProviderID, e.EmailProviderID} {
+		if id != nil && !providerIDs[*id] {
+			return fmt.Errorf("unknown provider %q", *id)
+		}
+	}
+
+	jobs := e.Jobs
+	if len(jobs) == 0 {
+		jobs = []string{string(models.JobTypePhone), string(models.JobTypeEmail)}
+	}
+	requested := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		if job != string(models.JobTypePhone) && job != string(models.JobTypeEmail) {
+			return fmt.Errorf("jobs must contain 'phone' and/or 'email', got %q", job)
+		}
+		requested[job] = true
+	}
+	for _, job := range e.CompletedJobs {
+		if !requested[job] {
+			return fmt.Errorf("completedJobs has %q, which is not one of its jobs", job)
+		}
+	}
+
+	return nil
+}
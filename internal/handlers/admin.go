@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/surfe/mock-api/internal/database"
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/problem"
+)
+
+// snapshotNamePattern keeps snapshot names usable as a path segment
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+// snapshot is a named copy of the contacts and enrichments
+type snapshot struct {
+	info models.SnapshotInfo
+	db   *database.Snapshot
+}
+
+// ResetState godoc
+// @Summary      Reset to the seed state
+// @Description  Stops the enrichments being processed and the webhook deliveries being retried, deletes every contact, enrichment, batch, provider attempt, webhook delivery and idempotency key, and seeds the built-in or fixture contacts and the static enrichments again. Providers, scenarios, fault rules, the clock and snapshots are kept
+// @Tags         admin
+// @Success      204  "No Content"
+// @Failure      500  {object}  models.Problem
+// @Router       /admin/reset [post]
+func (h *Handler) ResetState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	h.snapshotsMu.Lock()
+	defer h.snapshotsMu.Unlock()
+
+	h.pauseWorker()
+	defer h.resumeWorker()
+	h.cancelWebhooks()
+
+	if err := h.db.Reset(); err != nil {
+		writeInternalError(w, r, "failed to reset state", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSnapshots godoc
+// @Summary      List snapshots
+// @Description  Returns every snapshot taken since the server started, sorted by name
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   models.SnapshotInfo
+// @Router       /admin/snapshots [get]
+func (h *Handler) ListSnapshots(w http.ResponseWriter, r *http.Request) {
+	h.snapshotsMu.Lock()
+	defer h.snapshotsMu.Unlock()
+
+	infos := make([]models.SnapshotInfo, 0, len(h.snapshots))
+	for _, s := range h.snapshots {
+		infos = append(infos, s.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// CreateSnapshot godoc
+// @Summary      Take a snapshot
+// @Description  Copies the current contacts and enrichments, with their batches, provider attempts, webhook deliveries and idempotency keys, under a name. Taking a snapshot under an existing name replaces it. Snapshots live in memory and are lost on restart
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.SnapshotRequest  true  "Snapshot name"
+// @Success      201      {object}  models.SnapshotInfo
+// @Failure      400      {object}  models.Problem
+// @Failure      500      {object}  models.Problem
+// @Router       /admin/snapshots [post]
+func (h *Handler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	var req models.SnapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, problem.TypeInvalidBody, "invalid request body")
+		return
+	}
+	if !snapshotNamePattern.MatchString(req.Name) {
+		writeError(w, r, http.StatusBadRequest, "name must be 1 to 100 letters, digits, '-', '_' or '.'")
+		return
+	}
+
+	h.snapshotsMu.Lock()
+	defer h.snapshotsMu.Unlock()
+
+	tables, err := h.db.Snapshot()
+	if err != nil {
+		writeInternalError(w, r, "failed to take snapshot", err)
+		return
+	}
+
+	s := &snapshot{
+		info: models.SnapshotInfo{
+			Name:        req.Name,
+			CreatedAt:   h.config.Clock.Now().UTC().Format(time.RFC3339),
+			Contacts:    tables.Contacts(),
+			Enrichments: tables.Enrichments(),
+		},
+		db: tables,
+	}
+	h.snapshots[req.Name] = s
+
+	writeJSON(w, http.StatusCreated, s.info)
+}
+
+// RestoreSnapshot godoc
+// @Summary      Restore a snapshot
+// @Description  Stops the enrichments being processed and the webhook deliveries being retried, and replaces the contacts and enrichments with the snapshot's. Enrichments that were in progress when the snapshot was taken start over from their completed jobs
+// @Tags         admin
+// @Produce      json
+// @Param        name  path      string  true  "Snapshot name"
+// @Success      200   {object}  models.SnapshotInfo
+// @Failure      404   {object}  models.Problem
+// @Failure      500   {object}  models.Problem
+// @Router       /admin/snapshots/{name}/restore [post]
+func (h *Handler) RestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/admin/snapshots/"), "/restore")
+
+	h.snapshotsMu.Lock()
+	defer h.snapshotsMu.Unlock()
+
+	s, exists := h.snapshots[name]
+	if !exists {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeSnapshotNotFound, "snapshot not found")
+		return
+	}
+
+	h.pauseWorker()
+	defer h.resumeWorker()
+	h.cancelWebhooks()
+
+	if err := h.db.Restore(s.db); err != nil {
+		writeInternalError(w, r, "failed to restore snapshot", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.info)
+}
+
+// DeleteSnapshot godoc
+// @Summary      Delete a snapshot
+// @Description  Forgets a snapshot
+// @Tags         admin
+// @Param        name  path  string  true  "Snapshot name"
+// @Success      204  "No Content"
+// @Failure      404  {object}  models.Problem
+// @Router       /admin/snapshots/{name} [delete]
+func (h *Handler) DeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/admin/snapshots/")
+
+	h.snapshotsMu.Lock()
+	defer h.snapshotsMu.Unlock()
+
+	if _, exists := h.snapshots[name]; !exists {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeSnapshotNotFound, "snapshot not found")
+		return
+	}
+	delete(h.snapshots, name)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pauseWorker stops background processing, if a worker was configured
+func (h *Handler) pauseWorker() {
+	if h.config.Worker != nil {
+		h.config.Worker.Pause()
+	}
+}
+
+// cancelWebhooks stops the webhook deliveries of the state being replaced, if a dispatcher was configured
+func (h *Handler) cancelWebhooks() {
+	if h.config.Webhooks != nil {
+		h.config.Webhooks.CancelPending()
+	}
+}
+
+// resumeWorker picks up background processing again, if a worker was configured
+func (h *Handler) resumeWorker() {
+	if h.config.Worker != nil {
+		h.config.Worker.Resume()
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/surfe/mock-api/internal/database"
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/problem"
+	"github.com/surfe/mock-api/internal/rng"
+)
+
+// maxBulkItems is the largest number of enrichments a single bulk request can start
+const maxBulkItems = 100
+
+// BulkStartEnrichment godoc
+// @Summary      Start many enrichments at once
+// @Description  Starts one enrichment per item and groups them in a batch. Send either items, an array of enrichment start requests, or userIds with shared jobs and callbackUrl. Nothing is started if any item is invalid
+// @Tags         enrichment
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.BulkEnrichmentRequest  true   "Bulk enrichment request"
+// @Param        X-Seed   header    int                           false  "Seed from which every entry of userIds derives its own, used when the body has no seed"
+// @Success      201      {object}  models.BulkEnrichmentResponse
+// @Failure      400      {object}  models.Problem
+// @Router       /enrichment/bulk [post]
+func (h *Handler) BulkStartEnrichment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req models.BulkEnrichmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, problem.TypeInvalidBody, "invalid request body")
+		return
+	}
+
+	items := req.Items
+	if len(req.UserIDs) > 0 {
+		if len(items) > 0 {
+			writeError(w, r, http.StatusBadRequest, "send either items or userIds, not both")
+			return
+		}
+		if req.Seed == nil {
+			seed, err := parseSeedHeader(r)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+			req.Seed = seed
+		} else if err := validateSeed(*req.Seed); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		for i, userID := range req.UserIDs {
+			item := models.EnrichmentStartRequest{
+				UserID:        userID,
+				Jobs:          req.Jobs,
+				CallbackURL:   req.CallbackURL,
+				ProviderOrder: req.ProviderOrder,
+				Scenario:      req.Scenario,
+			}
+			// Each entry gets its own seed so the users of a seeded batch do not all share the same outcomes
+			if req.Seed != nil {
+				seed := rng.Derive(*req.Seed, strconv.Itoa(i)).Seed()
+				item.Seed = &seed
+			}
+			items = append(items, item)
+		}
+	} else if req.Seed != nil {
+		writeError(w, r, http.StatusBadRequest, "seed is only supported with userIds, set the seed of each item instead")
+		return
+	}
+
+	if len(items) == 0 {
+		writeError(w, r, http.StatusBadRequest, "items or userIds is required")
+		return
+	}
+	if len(items) > maxBulkItems {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("at most %d enrichments can be started at once", maxBulkItems))
+		return
+	}
+
+	// Validate everything up front so a bad item does not leave a half-started batch
+	options := make([]database.EnrichmentOptions, len(items))
+	for i, item := range items {
+		if item.Dedupe != "" && item.Dedupe != models.DedupeModeOff {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("item %d: dedupe is only supported by /enrichment/start", i))
+			return
+		}
+		opts, err := h.enrichmentOptions(item)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("item %d: %v", i, err))
+			return
+		}
+		options[i] = opts
+	}
+
+	for i := range options {
+		h.drawSeed(&options[i])
+	}
+
+	batchID, enrichments, err := h.db.CreateBatch(options)
+	if err != nil {
+		writeInternalError(w, r, "failed to create batch", err)
+		return
+	}
+
+	response := models.BulkEnrichmentResponse{
+		BatchID:       batchID,
+		EnrichmentIDs: make([]string, 0, len(enrichments)),
+		Message:       "Bulk enrichment started successfully",
+	}
+	for _, enrichment := range enrichments {
+		response.EnrichmentIDs = append(response.EnrichmentIDs, enrichment.ID)
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// GetBulkEnrichment godoc
+// @Summary      Get bulk enrichment progress
+// @Description  Returns the aggregate progress of a batch: how many enrichments are in each status, how many phone and email results were found, not found or are still pending, and the IDs of every enrichment in the batch
+// @Tags         enrichment
+// @Accept       json
+// @Produce      json
+// @Param        batchId   path      string  true  "Batch ID"
+// @Success      200  {object}  models.BatchStatus
+// @Failure      404  {object}  models.Problem
+// @Router       /enrichment/bulk/{batchId} [get]
+func (h *Handler) GetBulkEnrichment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/enrichment/bulk/")
+	if id == "" {
+		writeError(w, r, http.StatusBadRequest, "missing batch ID")
+		return
+	}
+
+	createdAt, err := h.db.GetBatchCreatedAt(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get batch", err)
+		return
+	}
+	if createdAt == "" {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeBatchNotFound, "batch not found")
+		return
+	}
+
+	children, err := h.db.ListEnrichments(database.EnrichmentFilter{
+		BatchID: id,
+		SortBy:  "created_at",
+	})
+	if err != nil {
+		writeInternalError(w, r, "failed to list batch enrichments", err)
+		return
+	}
+
+	status := models.BatchStatus{
+		ID:            id,
+		CreatedAt:     createdAt,
+		Total:         len(children),
+		Done:          true,
+		StatusCounts:  make(map[models.EnrichmentStatus]int),
+		Jobs:          make(map[models.JobType]*models.BatchJobProgress),
+		EnrichmentIDs: make([]string, 0, len(children)),
+	}
+
+	for _, child := range children {
+		enrichment, err := h.loadEnrichment(child.ID)
+		if err != nil {
+			writeInternalError(w, r, "failed to get enrichment", err)
+			return
+		}
+		if enrichment == nil {
+			continue
+		}
+
+		status.EnrichmentIDs = append(status.EnrichmentIDs, enrichment.ID)
+		status.StatusCounts[enrichment.Status]++
+		if !enrichment.Status.IsTerminal() {
+			status.Done = false
+		}
+
+		countJob(status.Jobs, models.JobTypePhone, enrichment.Phone, enrichment.Status)
+		countJob(status.Jobs, models.JobTypeEmail, enrichment.Email, enrichment.Status)
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// countJob adds one enrichment's job outcome to the batch totals
+func countJob(totals map[models.JobType]*models.BatchJobProgress, job models.JobType, js *models.JobStatus, status models.EnrichmentStatus) {
+	if js == nil {
+		return
+	}
+
+	progress, ok := totals[job]
+	if !ok {
+		progress = &models.BatchJobProgress{}
+		totals[job] = progress
+	}
+
+	progress.Requested++
+	switch {
+	case js.Result != "":
+		progress.Found++
+	case js.Pending && !status.IsTerminal():
+		progress.Pending++
+	default:
+		progress.NotFound++
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/surfe/mock-api/internal/clock"
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/problem"
+)
+
+// GetClock godoc
+// @Summary      Get the virtual clock
+// @Description  Returns the current virtual time, its speed and when each pending timer (worker poll, provider answer) is due
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  models.ClockState
+// @Failure      409  {object}  models.Problem
+// @Router       /admin/clock [get]
+func (h *Handler) GetClock(w http.ResponseWriter, r *http.Request) {
+	virtual, ok := h.virtualClock(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, clockState(virtual))
+}
+
+// SetClockSpeed godoc
+// @Summary      Set the virtual clock speed
+// @Description  Sets how many virtual seconds pass per real second for the whole enrichment lifecycle. 10 runs everything ten times faster, 0 pauses the clock so it only moves through /admin/clock/advance
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ClockSpeedRequest  true  "Clock speed"
+// @Success      200      {object}  models.ClockState
+// @Failure      400      {object}  models.Problem
+// @Failure      409      {object}  models.Problem
+// @Router       /admin/clock [put]
+func (h *Handler) SetClockSpeed(w http.ResponseWriter, r *http.Request) {
+	virtual, ok := h.virtualClock(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ClockSpeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, problem.TypeInvalidBody, "invalid request body")
+		return
+	}
+	if req.Speed == nil {
+		writeError(w, r, http.StatusBadRequest, "speed is required")
+		return
+	}
+	if *req.Speed < 0 {
+		writeError(w, r, http.StatusBadRequest, "speed must not be negative")
+		return
+	}
+
+	virtual.SetSpeed(*req.Speed)
+	writeJSON(w, http.StatusOK, clockState(virtual))
+}
+
+// AdvanceClock godoc
+// @Summary      Advance the virtual clock
+// @Description  Moves the virtual clock forward by a duration, or to the next pending timer with next set, firing every timer that becomes due. Timers scheduled in response, such as the next provider lookup, start from the new time, so step with next to stop at each provider answer
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ClockAdvanceRequest  true  "How far to advance"
+// @Success      200      {object}  models.ClockState
+// @Failure      400      {object}  models.Problem
+// @Failure      409      {object}  models.Problem
+// @Router       /admin/clock/advance [post]
+func (h *Handler) AdvanceClock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	virtual, ok := h.virtualClock(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ClockAdvanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, problem.TypeInvalidBody, "invalid request body")
+		return
+	}
+
+	switch {
+	case req.Next && req.Duration != "":
+		writeError(w, r, http.StatusBadRequest, "send either duration or next, not both")
+		return
+	case req.Next:
+		if _, ok := virtual.AdvanceToNext(); !ok {
+			writeProblem(w, r, http.StatusConflict, problem.TypeNoPendingTimer, "no timer is pending")
+			return
+		}
+	case req.Duration != "":
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d < 0 {
+			writeError(w, r, http.StatusBadRequest, "duration must be a positive Go duration such as '5s'")
+			return
+		}
+		virtual.Advance(d)
+	default:
+		writeError(w, r, http.StatusBadRequest, "duration or next is required")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, clockState(virtual))
+}
+
+// virtualClock returns the handler's clock if it can be controlled, writing an error otherwise
+func (h *Handler) virtualClock(w http.ResponseWriter, r *http.Request) (*clock.Virtual, bool) {
+	virtual, ok := h.config.Clock.(*clock.Virtual)
+	if !ok {
+		writeProblem(w, r, http.StatusConflict, problem.TypeNoVirtualClock, "the server is not running on a virtual clock")
+		return nil, false
+	}
+	return virtual, true
+}
+
+// clockState describes a virtual clock
+func clockState(c *clock.Virtual) models.ClockState {
+	state := models.ClockState{
+		Now:           c.Now().UTC().Format(time.RFC3339Nano),
+		Speed:         c.Speed(),
+		PendingTimers: []string{},
+	}
+	for _, deadline := range c.Pending() {
+		state.PendingTimers = append(state.PendingTimers, deadline.UTC().Format(time.RFC3339Nano))
+	}
+	return state
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/surfe/mock-api/internal/events"
+	"github.com/surfe/mock-api/internal/problem"
+)
+
+// sseHeartbeatInterval is how often a comment is sent to keep idle event streams open
+const sseHeartbeatInterval = 15 * time.Second
+
+// StreamEnrichmentEvents godoc
+// @Summary      Stream enrichment progress
+// @Description  Server-Sent Events stream of an enrichment's progress. A snapshot event with the current enrichment is sent first, followed by provider_changed, value_found, job_completed and status_changed events. The stream ends after a terminal status. Reconnect with Last-Event-ID to receive missed events, or a new snapshot if they are no longer kept
+// @Tags         enrichment
+// @Produce      text/event-stream
+// @Param        enrichmentId   path      string  true   "Enrichment ID"
+// @Param        Last-Event-ID  header    string  false  "ID of the last event received before reconnecting"
+// @Success      200  {object}  events.Event
+// @Failure      404  {object}  models.Problem
+// @Router       /enrichment/{enrichmentId}/events [get]
+func (h *Handler) StreamEnrichmentEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/enrichment/"), "/events")
+	if id == "" {
+		writeError(w, r, http.StatusBadRequest, "missing enrichment ID")
+		return
+	}
+
+	var lastEventID int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		parsed, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		lastEventID = parsed
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	// Subscribe before reading the current state so no event can slip through in between
+	sub := h.events.Subscribe()
+	defer sub.Close()
+	latestEventID := h.events.LastID()
+
+	enrichment, err := h.loadEnrichment(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get enrichment", err)
+		return
+	}
+	if enrichment == nil {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeEnrichmentNotFound, "enrichment not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// When resuming, replay what was missed instead of sending a snapshot. If the history no longer
+	// holds everything that was missed, the snapshot is the only way to catch up.
+	resumed := false
+	if lastEventID > 0 {
+		missed, complete := h.events.Since(id, lastEventID)
+		if complete {
+			resumed = true
+			for _, e := range missed {
+				writeSSEEvent(w, e)
+				lastEventID = e.ID
+				if e.Terminal() {
+					flusher.Flush()
+					return
+				}
+			}
+		}
+	}
+	if !resumed {
+		// The snapshot covers every event published so far
+		lastEventID = latestEventID
+	}
+	if !resumed || enrichment.Status.IsTerminal() {
+		writeSSE(w, "", "snapshot", enrichment)
+	}
+	flusher.Flush()
+
+	if enrichment.Status.IsTerminal() {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if e.EnrichmentID != id || e.ID <= lastEventID {
+				continue
+			}
+			writeSSEEvent(w, e)
+			flusher.Flush()
+			lastEventID = e.ID
+			if e.Terminal() {
+				return
+			}
+		case <-sub.Lagged:
+			// Events were dropped while the stream fell behind; replay this enrichment's from the
+			// history, or send a fresh snapshot if the history no longer holds them all
+			missed, complete := h.events.Since(id, lastEventID)
+			if !complete {
+				lastEventID = h.events.LastID()
+				enrichment, err := h.loadEnrichment(id)
+				if err != nil || enrichment == nil {
+					log.Printf("Error reloading enrichment %s for its event stream (trace %s): %v", id, problem.TraceID(r), err)
+					return
+				}
+				writeSSE(w, "", "snapshot", enrichment)
+				flusher.Flush()
+				if enrichment.Status.IsTerminal() {
+					return
+				}
+				continue
+			}
+			for _, e := range missed {
+				writeSSEEvent(w, e)
+				lastEventID = e.ID
+				if e.Terminal() {
+					flusher.Flush()
+					return
+				}
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSEEvent writes an enrichment event in Server-Sent Events format
+func writeSSEEvent(w http.ResponseWriter, e events.Event) {
+	writeSSE(w, strconv.FormatInt(e.ID, 10), string(e.Type), e)
+}
+
+// writeSSE writes a single Server-Sent Event with a JSON payload
+func writeSSE(w http.ResponseWriter, id, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
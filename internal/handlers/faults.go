@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/problem"
+)
+
+// GetFaults godoc
+// @Summary      Get the fault injection rules
+// @Description  Returns the rules that inject latency, error statuses, timeouts, dropped connections or truncated bodies into matching requests
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   models.FaultRule
+// @Router       /admin/faults [get]
+func (h *Handler) GetFaults(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.config.Faults.Rules())
+}
+
+// SetFaults godoc
+// @Summary      Replace the fault injection rules
+// @Description  Replaces every rule. A rule applies to requests matching its method and path pattern (e.g. "/enrichment/*") with its probability; each matching latency rule adds its delay and the first other matching fault is injected. Admin and docs routes are never affected. A single request can force faults with the X-Mock-Fault header instead, e.g. "latency=2000,error=503"
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      []models.FaultRule  true  "Fault rules"
+// @Success      200      {array}   models.FaultRule
+// @Failure      400      {object}  models.Problem
+// @Router       /admin/faults [put]
+func (h *Handler) SetFaults(w http.ResponseWriter, r *http.Request) {
+	var rules []models.FaultRule
+	if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, problem.TypeInvalidBody, "invalid request body")
+		return
+	}
+
+	if err := h.config.Faults.SetRules(rules); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.config.Faults.Rules())
+}
+
+// ClearFaults godoc
+// @Summary      Remove all fault injection rules
+// @Description  Stops injecting faults; requests sending the X-Mock-Fault header still get theirs
+// @Tags         admin
+// @Success      204  "No Content"
+// @Router       /admin/faults [delete]
+func (h *Handler) ClearFaults(w http.ResponseWriter, r *http.Request) {
+	h.config.Faults.SetRules(nil)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,62 +1,257 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"math/rand"
+	"fmt"
+	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/surfe/mock-api/internal/clock"
 	"github.com/surfe/mock-api/internal/data"
 	"github.com/surfe/mock-api/internal/database"
+	"github.com/surfe/mock-api/internal/events"
+	"github.com/surfe/mock-api/internal/fault"
 	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/normalize"
+	"github.com/surfe/mock-api/internal/problem"
+	"github.com/surfe/mock-api/internal/provider"
+	"github.com/surfe/mock-api/internal/rng"
+	"github.com/surfe/mock-api/internal/scenario"
+)
+
+const (
+	// defaultListLimit is the page size used when no limit is given
+	defaultListLimit = 20
+	// maxListLimit is the largest page size a client can request
+	maxListLimit = 100
+	// maxIdempotencyKeyLength is the longest Idempotency-Key header accepted
+	maxIdempotencyKeyLength = 255
+	// seedHeader sets the seed of a request when its body does not
+	seedHeader = "X-Seed"
 )
 
+// Config holds handler configuration
+type Config struct {
+	// IdempotencyKeyTTL is how long an Idempotency-Key is remembered and its response replayed
+	IdempotencyKeyTTL time.Duration
+
+	// Rand draws the seeds of enrichments started without one and the third-party latency
+	Rand *rng.Source
+
+	// Clock paces the third-party latency; a *clock.Virtual can be controlled through /admin/clock
+	Clock clock.Clock
+
+	// Faults holds the fault injection rules managed through /admin/faults
+	Faults *fault.Injector
+
+	// Worker is paused while /admin/reset or a snapshot restore replaces the state
+	Worker Pauser
+
+	// Webhooks delivers the enrichments failed by deleting their contact to their callback URL,
+	// and has its pending deliveries cancelled by /admin/reset and snapshot restores
+	Webhooks Dispatcher
+
+	// RequireIfMatch rejects PUT /contact/{id} without an If-Match header with 428
+	RequireIfMatch bool
+}
+
+// Pauser stops background processing while the state is replaced
+type Pauser interface {
+	// Pause stops processing and waits until nothing touches the state anymore
+	Pause()
+
+	// Resume picks up processing again from the current state
+	Resume()
+}
+
+// Dispatcher delivers an enrichment that reached a final status to its callback URL
+type Dispatcher interface {
+	Dispatch(enrichmentID string)
+
+	// CancelPending stops the deliveries in flight or waiting to be retried
+	CancelPending()
+}
+
+// DefaultConfig returns the default handler configuration
+func DefaultConfig() Config {
+	return Config{
+		IdempotencyKeyTTL: 24 * time.Hour,
+		Rand:              rng.New(time.Now().UnixNano()),
+		Clock:             clock.Real{},
+		Faults:            fault.New(nil),
+	}
+}
+
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
-	data *data.MockData
-	db   *database.DB
+	data   *data.MockData
+	db     *database.DB
+	events *events.Broker
+	config Config
+
+	// idempotencyMu serializes requests carrying an Idempotency-Key so a key is only ever used once
+	idempotencyMu sync.Mutex
+	// dedupeMu serializes start requests that look for running enrichments to reuse
+	dedupeMu sync.Mutex
+
+	// snapshotsMu serializes resets, snapshots and restores
+	snapshotsMu sync.Mutex
+	snapshots   map[string]*snapshot
 }
 
-// NewHandler creates a new handler with the given mock data and database
-func NewHandler(d *data.MockData, db *database.DB) *Handler {
-	return &Handler{data: d, db: db}
+// NewHandler creates a new handler with the given mock data, database, event broker and configuration
+func NewHandler(d *data.MockData, db *database.DB, broker *events.Broker, config Config) *Handler {
+	return &Handler{data: d, db: db, events: broker, config: config, snapshots: make(map[string]*snapshot)}
+}
+
+// contactSorts maps the sort parameter of GET /contacts to the sort keys of database.ContactFilter
+var contactSorts = map[string]string{
+	"name":      "name",
+	"firstName": "first_name",
+	"lastName":  "last_name",
+	"email":     "email",
+	"phone":     "phone",
+	"company":   "company",
+	"jobTitle":  "job_title",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
 }
 
 // GetContacts godoc
 // @Summary      Get all contacts
-// @Description  Returns all available contacts from the database
+// @Description  Returns the contacts from the database, optionally searched, filtered, sorted and paginated. Contacts are sorted by last then first name by default and ties are broken by ID, so pages stay stable. Without a limit every matching contact is returned. X-Total-Count holds the number of matching contacts across all pages and X-Next-Cursor, set when there are more, is the cursor of the next page
 // @Tags         contacts
 // @Accept       json
 // @Produce      json
+// @Param        q         query     string  false  "Case-insensitive search across name, email, company and job title"
+// @Param        company   query     string  false  "Only contacts of this company (case-insensitive)"
+// @Param        hasPhone  query     bool    false  "Only contacts with (true) or without (false) a phone"
+// @Param        hasEmail  query     bool    false  "Only contacts with (true) or without (false) an email"
+// @Param        sort      query     string  false  "Sort field: name (default), firstName, lastName, email, phone, company, jobTitle, createdAt or updatedAt"
+// @Param        order     query     string  false  "Sort order: asc (default) or desc"
+// @Param        limit     query     int     false  "Page size (max 100)"
+// @Param        cursor    query     string  false  "Cursor returned in X-Next-Cursor by the previous page"
 // @Success      200  {array}   models.Contact
+// @Header       200  {integer}  X-Total-Count  "Number of contacts matching the filters"
+// @Header       200  {string}   X-Next-Cursor  "Cursor of the next page, if any"
+// @Failure      400  {object}  models.Problem
+// @Failure      500  {object}  models.Problem
 // @Router       /contacts [get]
 func (h *Handler) GetContacts(w http.ResponseWriter, r *http.Request) {
-	contacts := h.data.GetAllContacts()
-	writeJSON(w, http.StatusOK, contacts)
+	query := r.URL.Query()
+	filter := database.ContactFilter{
+		Search:  strings.TrimSpace(query.Get("q")),
+		Company: strings.TrimSpace(query.Get("company")),
+		SortBy:  "name",
+	}
+
+	for param, target := range map[string]**bool{
+		"hasPhone": &filter.HasPhone,
+		"hasEmail": &filter.HasEmail,
+	} {
+		if value := query.Get(param); value != "" {
+			present, err := strconv.ParseBool(value)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, param+" must be 'true' or 'false'")
+				return
+			}
+			*target = &present
+		}
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		sortBy, ok := contactSorts[sort]
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, "sort must be 'name', 'firstName', 'lastName', 'email', 'phone', 'company', 'jobTitle', 'createdAt' or 'updatedAt'")
+			return
+		}
+		filter.SortBy = sortBy
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		writeError(w, r, http.StatusBadRequest, "order must be 'asc' or 'desc'")
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxListLimit {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+			return
+		}
+		filter.Limit = n
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil || c.Sort != filter.SortBy || c.Descending != filter.Descending {
+			writeError(w, r, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		filter.AfterValue = c.Value
+		filter.AfterID = c.ID
+	}
+
+	page, err := h.db.ListContacts(filter)
+	if err != nil {
+		writeInternalError(w, r, "failed to list contacts", err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextID != "" {
+		w.Header().Set("X-Next-Cursor", encodeCursor(listCursor{Sort: filter.SortBy, Descending: filter.Descending, Value: page.NextValue, ID: page.NextID}))
+	}
+	writeJSON(w, http.StatusOK, page.Contacts)
 }
 
 // GetContact godoc
 // @Summary      Get contact by ID
-// @Description  Returns the basic information around the contact based on their ID
+// @Description  Returns the basic information around the contact based on their ID, with its version as the ETag. Sending that ETag in If-None-Match returns 304 while the contact is unchanged
 // @Tags         contacts
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "Contact ID"
-// @Success      200  {object}  models.Contact
-// @Failure      404  {object}  models.ErrorResponse
+// @Param        id             path      string  true   "Contact ID"
+// @Param        If-None-Match  header    string  false  "ETag of the copy the client holds"
+// @Success      200            {object}  models.Contact
+// @Success      304            "Not Modified"
+// @Failure      404            {object}  models.Problem
 // @Router       /contact/{id} [get]
 func (h *Handler) GetContact(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/contact/")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "missing contact ID")
+		writeError(w, r, http.StatusBadRequest, "missing contact ID")
 		return
 	}
 
-	contact, exists := h.data.GetContact(id)
-	if !exists {
-		writeError(w, http.StatusNotFound, "contact not found")
+	contact, err := h.db.GetContact(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get contact", err)
+		return
+	}
+	if contact == nil {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeContactNotFound, "contact not found")
+		return
+	}
+
+	etag := contactETag(contact)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -65,111 +260,450 @@ func (h *Handler) GetContact(w http.ResponseWriter, r *http.Request) {
 
 // UpdateContact godoc
 // @Summary      Update contact phone and/or email
-// @Description  Updates the phone and/or email of a contact by ID
+// @Description  Updates the phone and/or email of a contact by ID, at least one of which is required; an empty string clears it. The phone is kept in the form it was given in, with its E.164 form as phoneE164, and the email with a lowercase domain. Invalid fields are listed in the errors of the 400 response. With If-Match, the update only applies if the contact's ETag still matches, 412 otherwise; the server can be configured to require it (REQUIRE_IF_MATCH=true), answering 428 without it
 // @Tags         contacts
 // @Accept       json
 // @Produce      json
-// @Param        id       path      string                      true  "Contact ID"
-// @Param        request  body      models.UpdateContactRequest true  "Update request"
-// @Success      200      {object}  models.Contact
-// @Failure      400      {object}  models.ErrorResponse
-// @Failure      404      {object}  models.ErrorResponse
+// @Param        id        path      string                      true   "Contact ID"
+// @Param        If-Match  header    string                      false  "ETag of the contact the update is based on"
+// @Param        request   body      models.UpdateContactRequest true   "Update request"
+// @Success      200       {object}  models.Contact
+// @Failure      400       {object}  models.Problem
+// @Failure      404       {object}  models.Problem
+// @Failure      412       {object}  models.Problem
+// @Failure      428       {object}  models.Problem
 // @Router       /contact/{id} [put]
 func (h *Handler) UpdateContact(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/contact/")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "missing contact ID")
+		writeError(w, r, http.StatusBadRequest, "missing contact ID")
 		return
 	}
 
-	// Check if contact exists
-	_, exists := h.data.GetContact(id)
-	if !exists {
-		writeError(w, http.StatusNotFound, "contact not found")
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" && h.config.RequireIfMatch {
+		writeError(w, r, http.StatusPreconditionRequired, "If-Match is required, send the ETag of GET /contact/{id}")
 		return
 	}
 
 	var req models.UpdateContactRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeProblem(w, r, http.StatusBadRequest, problem.TypeInvalidBody, "invalid request body")
+		return
+	}
+
+	// An update without fields would change nothing but the version, failing other clients' If-Match
+	if req.Phone == nil && req.Email == nil {
+		writeValidationError(w, r, []models.FieldError{
+			normalize.FieldError("phone", &normalize.Error{Rule: normalize.RuleRequired, Message: "or email is required"}),
+			normalize.FieldError("email", &normalize.Error{Rule: normalize.RuleRequired, Message: "or phone is required"}),
+		})
 		return
 	}
 
-	// Update phone if provided
+	// Normalize the phone and/or email that were provided, an empty one clears the field
+	var phone *normalize.Phone
+	var email *string
+	var fieldErrors []models.FieldError
 	if req.Phone != nil {
-		if err := h.data.UpdateContactPhone(id, *req.Phone); err != nil {
-			writeError(w, http.StatusInternalServerError, "failed to update phone")
-			return
+		phone = &normalize.Phone{}
+		if strings.TrimSpace(*req.Phone) != "" {
+			parsed, err := normalize.ParsePhone(*req.Phone)
+			if err != nil {
+				fieldErrors = append(fieldErrors, normalize.FieldError("phone", err))
+			}
+			*phone = parsed
 		}
 	}
-
-	// Update email if provided
 	if req.Email != nil {
-		if err := h.data.UpdateContactEmail(id, *req.Email); err != nil {
-			writeError(w, http.StatusInternalServerError, "failed to update email")
+		email = new(string)
+		if strings.TrimSpace(*req.Email) != "" {
+			normalized, err := normalize.Email(*req.Email)
+			if err != nil {
+				fieldErrors = append(fieldErrors, normalize.FieldError("email", err))
+			}
+			*email = normalized
+		}
+	}
+	if len(fieldErrors) > 0 {
+		writeValidationError(w, r, fieldErrors)
+		return
+	}
+
+	// If-Match is checked against the contact as it is now, and the update only applies
+	// if no one else, the worker included, changed it in between
+	version := 0
+	if ifMatch != "" {
+		current, err := h.db.GetContact(id)
+		if err != nil {
+			writeInternalError(w, r, "failed to get contact", err)
+			return
+		}
+		if current == nil {
+			writeProblem(w, r, http.StatusNotFound, problem.TypeContactNotFound, "contact not found")
+			return
+		}
+		if !etagMatches(ifMatch, contactETag(current), false) {
+			w.Header().Set("ETag", contactETag(current))
+			writeProblem(w, r, http.StatusPreconditionFailed, problem.TypeContactModified, "contact was modified since the If-Match ETag was read")
 			return
 		}
+		version = current.Version
+	}
+
+	contact, updated, err := h.db.UpdateContact(id, version, phone, email)
+	if err != nil {
+		writeInternalError(w, r, "failed to update contact", err)
+		return
+	}
+	if contact == nil {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeContactNotFound, "contact not found")
+		return
+	}
+	w.Header().Set("ETag", contactETag(contact))
+	if !updated {
+		writeProblem(w, r, http.StatusPreconditionFailed, problem.TypeContactModified, "contact was modified since the If-Match ETag was read")
+		return
 	}
 
-	// Return updated contact
-	contact, _ := h.data.GetContact(id)
 	writeJSON(w, http.StatusOK, contact)
 }
 
+// contactETag returns the ETag of a contact, its quoted version
+func contactETag(contact *models.Contact) string {
+	return `"` + strconv.Itoa(contact.Version) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header lists the ETag or is "*".
+// Weak comparison, used for If-None-Match, ignores the W/ prefix; strong comparison never matches weak ETags.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateContact godoc
+// @Summary      Create a contact
+// @Description  Creates a contact with a server-generated ID. firstName and lastName are required. The phone is kept in the form it was given in, with its E.164 form as phoneE164, and the email with a lowercase domain. Invalid fields are listed in the errors of the 400 response
+// @Tags         contacts
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.CreateContactRequest  true  "Contact"
+// @Success      201      {object}  models.Contact
+// @Failure      400      {object}  models.Problem
+// @Failure      500      {object}  models.Problem
+// @Router       /contacts [post]
+func (h *Handler) CreateContact(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, problem.TypeInvalidBody, "invalid request body")
+		return
+	}
+
+	contact, fieldErrors := normalize.Contact(models.Contact{
+		ID:        uuid.New().String(),
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Email:     req.Email,
+		Phone:     req.Phone,
+		Company:   req.Company,
+		JobTitle:  req.JobTitle,
+	})
+	if len(fieldErrors) > 0 {
+		writeValidationError(w, r, fieldErrors)
+		return
+	}
+
+	created, err := h.db.CreateContact(contact)
+	if err != nil {
+		writeInternalError(w, r, "failed to create contact", err)
+		return
+	}
+
+	w.Header().Set("ETag", contactETag(created))
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// DeleteContact godoc
+// @Summary      Delete a contact
+// @Description  Deletes a contact by ID. Its pending and in_progress enrichments fail with the error "contact was deleted", keeping whatever was found so far, and are delivered to their callback URL. Finished enrichments are kept as they are
+// @Tags         contacts
+// @Param        id   path  string  true  "Contact ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  models.Problem
+// @Failure      500  {object}  models.Problem
+// @Router       /contact/{id} [delete]
+func (h *Handler) DeleteContact(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/contact/")
+	if id == "" {
+		writeError(w, r, http.StatusBadRequest, "missing contact ID")
+		return
+	}
+
+	deleted, failed, err := h.db.DeleteContact(id, "contact was deleted")
+	if err != nil {
+		writeInternalError(w, r, "failed to delete contact", err)
+		return
+	}
+	if !deleted {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeContactNotFound, "contact not found")
+		return
+	}
+	for _, enrichmentID := range failed {
+		h.events.Publish(events.Event{
+			Type:         events.TypeStatusChanged,
+			EnrichmentID: enrichmentID,
+			UserID:       id,
+			Status:       models.EnrichmentStatusFailed,
+		})
+		if h.config.Webhooks != nil {
+			h.config.Webhooks.Dispatch(enrichmentID)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // StartEnrichment godoc
 // @Summary      Start an enrichment
-// @Description  Starts an enrichment process, taking the userID and additional optional payload
+// @Description  Starts an enrichment process, taking the userID and additional optional payload. Set dedupe to "existing" to get back a running enrichment that already covers the requested jobs, or to "missing" to only start the jobs no running enrichment covers; deduplicated requests return 200 with deduplicated set. Starting an enrichment with the seed of another one replays the same provider delays and outcomes
 // @Tags         enrichment
 // @Accept       json
 // @Produce      json
-// @Param        request  body      models.EnrichmentStartRequest  true  "Enrichment request"
+// @Param        request          body      models.EnrichmentStartRequest  true   "Enrichment request"
+// @Param        Idempotency-Key  header    string                         false  "Replays the original response when the same request is sent again with this key"
+// @Param        X-Seed           header    int                            false  "Seed of the enrichment, used when the body has no seed"
+// @Success      200      {object}  models.EnrichmentStartResponse
 // @Success      201      {object}  models.EnrichmentStartResponse
-// @Failure      400      {object}  models.ErrorResponse
+// @Failure      400      {object}  models.Problem
+// @Failure      422      {object}  models.Problem
 // @Router       /enrichment/start [post]
 func (h *Handler) StartEnrichment(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	var req models.EnrichmentStartRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeProblem(w, r, http.StatusBadRequest, problem.TypeInvalidBody, "invalid request body")
 		return
 	}
+	if req.Seed == nil {
+		seed, err := parseSeedHeader(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		req.Seed = seed
+	}
 
-	if req.UserID == "" {
-		writeError(w, http.StatusBadRequest, "userId is required")
+	opts, err := h.enrichmentOptions(req)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Validate jobs if provided
-	var jobs []string
-	if len(req.Jobs) > 0 {
-		for _, job := range req.Jobs {
-			if job == models.JobTypePhone || job == models.JobTypeEmail {
-				jobs = append(jobs, string(job))
+	key := r.Header.Get("Idempotency-Key")
+	if len(key) > maxIdempotencyKeyLength {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength))
+		return
+	}
+
+	var requestHash string
+	if key != "" {
+		h.idempotencyMu.Lock()
+		defer h.idempotencyMu.Unlock()
+
+		requestHash = hashRequest(req)
+		record, err := h.idempotencyRecord(key)
+		if err != nil {
+			writeInternalError(w, r, "failed to check idempotency key", err)
+			return
+		}
+		if record != nil {
+			if record.RequestHash != requestHash {
+				writeProblem(w, r, http.StatusUnprocessableEntity, problem.TypeIdempotencyKeyReused, "Idempotency-Key was already used with a different request body")
+				return
 			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			writeRawJSON(w, record.StatusCode, record.Response)
+			return
 		}
-		if len(jobs) == 0 {
-			writeError(w, http.StatusBadRequest, "jobs must contain 'phone' and/or 'email'")
+	}
+
+	message := "Enrichment started successfully"
+	var covering []string
+
+	if req.Dedupe == models.DedupeModeExisting || req.Dedupe == models.DedupeModeMissing {
+		// Serialize deduplicated starts so two concurrent requests cannot both miss each other
+		h.dedupeMu.Lock()
+		defer h.dedupeMu.Unlock()
+
+		active, err := h.activeEnrichments(opts.UserID)
+		if err != nil {
+			writeInternalError(w, r, "failed to check running enrichments", err)
 			return
 		}
+
+		requested := opts.Jobs
+		if len(requested) == 0 {
+			requested = []string{string(models.JobTypePhone)}
+		}
+
+		if req.Dedupe == models.DedupeModeExisting {
+			for _, a := range active {
+				if containsAll(a.Jobs, requested) {
+					h.respondStart(w, key, requestHash, http.StatusOK, models.EnrichmentStartResponse{
+						ID:           a.ID,
+						Status:       a.Status,
+						Message:      "Enrichment already running for the requested jobs",
+						Jobs:         toJobTypes(a.Jobs),
+						Deduplicated: true,
+					})
+					return
+				}
+			}
+		} else {
+			covered := make(map[string]bool)
+			var newest *activeEnrichment
+			for i, a := range active {
+				overlaps := false
+				for _, job := range a.Jobs {
+					if containsAll(requested, []string{job}) && !covered[job] {
+						covered[job] = true
+						overlaps = true
+					}
+				}
+				if overlaps {
+					covering = append(covering, a.ID)
+					if newest == nil {
+						newest = &active[i]
+					}
+				}
+			}
+
+			var missing []string
+			for _, job := range requested {
+				if !covered[job] {
+					missing = append(missing, job)
+				}
+			}
+
+			if len(missing) == 0 {
+				h.respondStart(w, key, requestHash, http.StatusOK, models.EnrichmentStartResponse{
+					ID:                  newest.ID,
+					Status:              newest.Status,
+					Message:             "Enrichment already running for the requested jobs",
+					Jobs:                toJobTypes(newest.Jobs),
+					Deduplicated:        true,
+					ActiveEnrichmentIDs: covering,
+				})
+				return
+			}
+			if len(covering) > 0 {
+				opts.Jobs = missing
+				for job := range opts.ProviderOrder {
+					if !containsAll(missing, []string{job}) {
+						delete(opts.ProviderOrder, job)
+					}
+				}
+				if opts.Scenario != nil {
+					for job := range opts.Scenario.Jobs {
+						if !containsAll(missing, []string{string(job)}) {
+							delete(opts.Scenario.Jobs, job)
+						}
+					}
+				}
+				message = "Enrichment started for the jobs not already running"
+			}
+		}
 	}
 
-	enrichment, err := h.db.CreateEnrichment(req.UserID, jobs, req.Contact)
+	h.drawSeed(&opts)
+	enrichment, err := h.db.CreateEnrichmentWithOptions(opts)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to create enrichment")
+		writeInternalError(w, r, "failed to create enrichment", err)
 		return
 	}
 
-	response := models.EnrichmentStartResponse{
-		ID:      enrichment.ID,
-		Status:  enrichment.Status,
-		Message: "Enrichment started successfully",
+	jobs := opts.Jobs
+	if len(jobs) == 0 {
+		jobs = []string{string(models.JobTypePhone)}
 	}
 
-	writeJSON(w, http.StatusCreated, response)
+	h.respondStart(w, key, requestHash, http.StatusCreated, models.EnrichmentStartResponse{
+		ID:                  enrichment.ID,
+		Status:              enrichment.Status,
+		Message:             message,
+		Jobs:                toJobTypes(jobs),
+		Seed:                enrichment.Seed,
+		ActiveEnrichmentIDs: covering,
+	})
+}
+
+// respondStart writes the response of a start request, remembering it when an Idempotency-Key was sent
+func (h *Handler) respondStart(w http.ResponseWriter, key, requestHash string, status int, response models.EnrichmentStartResponse) {
+	if key != "" {
+		body, _ := json.Marshal(response)
+		err := h.db.SaveIdempotencyKey(&database.IdempotencyRecord{
+			Key:         key,
+			RequestHash: requestHash,
+			StatusCode:  status,
+			Response:    body,
+		})
+		if err != nil {
+			log.Printf("Error saving idempotency key %q: %v", key, err)
+		}
+	}
+
+	writeJSON(w, status, response)
+}
+
+// activeEnrichment is a running enrichment and the jobs it requested
+type activeEnrichment struct {
+	ID     string
+	Status models.EnrichmentStatus
+	Jobs   []string
+}
+
+// activeEnrichments returns the pending and in-progress enrichments of a user, newest first.
+// The static seeded enrichments never finish and are left out.
+func (h *Handler) activeEnrichments(userID string) ([]activeEnrichment, error) {
+	enrichments, err := h.db.ListEnrichments(database.EnrichmentFilter{
+		UserID:        userID,
+		Statuses:      []models.EnrichmentStatus{models.EnrichmentStatusPending, models.EnrichmentStatusInProgress},
+		ExcludeStatic: true,
+		SortBy:        "created_at",
+		Descending:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]activeEnrichment, 0, len(enrichments))
+	for _, e := range enrichments {
+		jobs, _, err := h.db.GetEnrichmentJobs(e.ID)
+		if err != nil {
+			return nil, err
+		}
+		active = append(active, activeEnrichment{ID: e.ID, Status: e.Status, Jobs: jobs})
+	}
+
+	return active, nil
 }
 
 // GetEnrichment godoc
@@ -180,123 +714,433 @@ func (h *Handler) StartEnrichment(w http.ResponseWriter, r *http.Request) {
 // @Produce      json
 // @Param        enrichmentId   path      string  true  "Enrichment ID"
 // @Success      200  {object}  models.Enrichment
-// @Failure      404  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.Problem
 // @Router       /enrichment/{enrichmentId} [get]
 func (h *Handler) GetEnrichment(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/enrichment/")
 	if id == "" || id == "start" {
-		writeError(w, http.StatusBadRequest, "missing enrichment ID")
+		writeError(w, r, http.StatusBadRequest, "missing enrichment ID")
+		return
+	}
+
+	enrichment, err := h.loadEnrichment(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get enrichment", err)
+		return
+	}
+	if enrichment == nil {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeEnrichmentNotFound, "enrichment not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrichment)
+}
+
+// CancelEnrichment godoc
+// @Summary      Cancel an enrichment
+// @Description  Cancels a pending or in-progress enrichment. The provider lookup in flight is interrupted and any result already found is kept
+// @Tags         enrichment
+// @Accept       json
+// @Produce      json
+// @Param        enrichmentId   path      string  true  "Enrichment ID"
+// @Success      200  {object}  models.Enrichment
+// @Failure      404  {object}  models.Problem
+// @Failure      409  {object}  models.Problem
+// @Router       /enrichment/{enrichmentId} [delete]
+func (h *Handler) CancelEnrichment(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/enrichment/")
+	if id == "" || id == "start" {
+		writeError(w, r, http.StatusBadRequest, "missing enrichment ID")
 		return
 	}
 
-	enrichment, phoneProviderID, emailProviderID, err := h.db.GetEnrichmentWithProviders(id)
+	enrichment, err := h.db.GetEnrichment(id)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to get enrichment")
+		writeInternalError(w, r, "failed to get enrichment", err)
 		return
 	}
 	if enrichment == nil {
-		writeError(w, http.StatusNotFound, "enrichment not found")
+		writeProblem(w, r, http.StatusNotFound, problem.TypeEnrichmentNotFound, "enrichment not found")
+		return
+	}
+
+	isStatic, err := h.db.IsStaticEnrichment(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get enrichment", err)
+		return
+	}
+	if isStatic {
+		writeProblem(w, r, http.StatusConflict, problem.TypeStaticEnrichment, "static enrichments cannot be cancelled")
+		return
+	}
+
+	cancelled, err := h.db.CancelEnrichment(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to cancel enrichment", err)
+		return
+	}
+	if !cancelled {
+		writeProblem(w, r, http.StatusConflict, problem.TypeEnrichmentFinished, "enrichment is already "+string(enrichment.Status))
+		return
+	}
+	h.events.Publish(events.Event{
+		Type:         events.TypeStatusChanged,
+		EnrichmentID: id,
+		UserID:       enrichment.UserID,
+		Status:       models.EnrichmentStatusCancelled,
+	})
+
+	enrichment, err = h.loadEnrichment(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get enrichment", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrichment)
+}
+
+// RetryEnrichment godoc
+// @Summary      Retry an enrichment
+// @Description  Starts a new enrichment for the jobs of a failed, cancelled or completed enrichment that came back empty. The new enrichment re-uses the original contact info and links back to it through retryOf
+// @Tags         enrichment
+// @Accept       json
+// @Produce      json
+// @Param        enrichmentId   path      string  true  "Enrichment ID"
+// @Success      201  {object}  models.EnrichmentStartResponse
+// @Failure      404  {object}  models.Problem
+// @Failure      409  {object}  models.Problem
+// @Router       /enrichment/{enrichmentId}/retry [post]
+func (h *Handler) RetryEnrichment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/enrichment/"), "/retry")
+	if id == "" {
+		writeError(w, r, http.StatusBadRequest, "missing enrichment ID")
 		return
 	}
 
-	// Get jobs and completed jobs to determine status
-	jobs, completedJobs, err := h.db.GetEnrichmentJobs(id)
+	original, err := h.db.GetEnrichment(id)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to get enrichment jobs")
+		writeInternalError(w, r, "failed to get enrichment", err)
+		return
+	}
+	if original == nil {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeEnrichmentNotFound, "enrichment not found")
 		return
 	}
 
-	// Populate Phone JobStatus
-	phoneRequested := false
-	phoneCompleted := false
+	isStatic, err := h.db.IsStaticEnrichment(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get enrichment", err)
+		return
+	}
+	if isStatic {
+		writeProblem(w, r, http.StatusConflict, problem.TypeStaticEnrichment, "static enrichments cannot be retried")
+		return
+	}
+
+	if !original.Status.IsTerminal() {
+		writeProblem(w, r, http.StatusConflict, problem.TypeEnrichmentRunning, "enrichment is still running")
+		return
+	}
+
+	jobs, _, err := h.db.GetEnrichmentJobs(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get enrichment jobs", err)
+		return
+	}
+
+	// Only re-queue the jobs that came back empty
+	var emptyJobs []string
 	for _, job := range jobs {
-		if job == "phone" {
-			phoneRequested = true
-			break
+		value := ""
+		if original.Result != nil {
+			if job == "phone" {
+				value = original.Result.Phone
+			} else if job == "email" {
+				value = original.Result.Email
+			}
 		}
-	}
-	for _, completed := range completedJobs {
-		if completed == "phone" {
-			phoneCompleted = true
-			break
+		if value == "" {
+			emptyJobs = append(emptyJobs, job)
 		}
 	}
+	if len(emptyJobs) == 0 {
+		writeProblem(w, r, http.StatusConflict, problem.TypeNothingToRetry, "all requested jobs already have results")
+		return
+	}
 
-	if phoneRequested {
-		phoneStatus := &models.JobStatus{
-			Pending: !phoneCompleted,
-		}
+	contactInfo, err := h.db.GetEnrichmentContactInfo(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get enrichment contact info", err)
+		return
+	}
 
-		// Set current provider
-		if phoneProviderID != nil && *phoneProviderID != "" {
-			provider, exists := h.data.GetProvider(*phoneProviderID)
-			if exists {
-				phoneStatus.CurrentProvider = &provider
+	// Walk the re-queued jobs through the same providers as the original
+	originalOrder, err := h.db.GetEnrichmentProviderOrder(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get enrichment provider order", err)
+		return
+	}
+	var providerOrder map[string][]string
+	for _, job := range emptyJobs {
+		if order, ok := originalOrder[job]; ok {
+			if providerOrder == nil {
+				providerOrder = make(map[string][]string)
 			}
+			providerOrder[job] = order
 		}
+	}
+
+	// Derive the seed from the original so a seeded enrichment retries the same way every time,
+	// without replaying the misses it is retrying
+	seed := h.config.Rand.Seed()
+	if original.Seed != nil {
+		seed = rng.Derive(*original.Seed, "retry").Seed()
+	}
+
+	enrichment, err := h.db.CreateEnrichmentWithOptions(database.EnrichmentOptions{
+		UserID:        original.UserID,
+		Jobs:          emptyJobs,
+		ContactInfo:   contactInfo,
+		RetryOf:       original.ID,
+		CallbackURL:   original.CallbackURL,
+		ProviderOrder: providerOrder,
+		Seed:          &seed,
+	})
+	if err != nil {
+		writeInternalError(w, r, "failed to create enrichment", err)
+		return
+	}
+
+	response := models.EnrichmentStartResponse{
+		ID:      enrichment.ID,
+		Status:  enrichment.Status,
+		Message: "Enrichment retry started successfully",
+		RetryOf: enrichment.RetryOf,
+		Seed:    enrichment.Seed,
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// ListEnrichments godoc
+// @Summary      List enrichments
+// @Description  Returns a page of enrichments, optionally filtered by user, status, job type and creation date. Use nextCursor from the response as cursor to fetch the next page
+// @Tags         enrichment
+// @Accept       json
+// @Produce      json
+// @Param        userId         query     string  false  "Only enrichments for this user ID"
+// @Param        batchId        query     string  false  "Only enrichments started in this bulk batch"
+// @Param        status         query     string  false  "Comma-separated statuses (pending, in_progress, completed, failed, cancelled)"
+// @Param        job            query     string  false  "Only enrichments that requested this job type (phone or email)"
+// @Param        createdAfter   query     string  false  "Only enrichments created at or after this RFC3339 time"
+// @Param        createdBefore  query     string  false  "Only enrichments created before this RFC3339 time"
+// @Param        sort           query     string  false  "Sort field: createdAt (default) or updatedAt"
+// @Param        order          query     string  false  "Sort order: desc (default) or asc"
+// @Param        limit          query     int     false  "Page size (default 20, max 100)"
+// @Param        cursor         query     string  false  "Cursor returned as nextCursor by the previous page"
+// @Success      200  {object}  models.EnrichmentList
+// @Failure      400  {object}  models.Problem
+// @Router       /enrichments [get]
+func (h *Handler) ListEnrichments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
 
-		// Set result and message
-		if phoneCompleted {
-			if enrichment.Result != nil && enrichment.Result.Phone != "" {
-				phoneStatus.Result = enrichment.Result.Phone
-				phoneStatus.Message = "Phone number found successfully"
-			} else {
-				phoneStatus.Result = ""
-				phoneStatus.Message = "Phone number not found after checking all providers"
+	query := r.URL.Query()
+	filter := database.EnrichmentFilter{
+		UserID:     query.Get("userId"),
+		BatchID:    query.Get("batchId"),
+		SortBy:     "created_at",
+		Descending: true,
+		Limit:      defaultListLimit,
+	}
+
+	if statuses := query.Get("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			switch models.EnrichmentStatus(status) {
+			case models.EnrichmentStatusPending, models.EnrichmentStatusInProgress, models.EnrichmentStatusCompleted,
+				models.EnrichmentStatusFailed, models.EnrichmentStatusCancelled:
+				filter.Statuses = append(filter.Statuses, models.EnrichmentStatus(status))
+			default:
+				writeError(w, r, http.StatusBadRequest, "invalid status: "+status)
+				return
 			}
-		} else {
-			phoneStatus.Message = "Searching for phone number..."
 		}
+	}
 
-		enrichment.Phone = phoneStatus
+	if job := query.Get("job"); job != "" {
+		if models.JobType(job) != models.JobTypePhone && models.JobType(job) != models.JobTypeEmail {
+			writeError(w, r, http.StatusBadRequest, "job must be 'phone' or 'email'")
+			return
+		}
+		filter.Job = job
 	}
 
-	// Populate Email JobStatus
-	emailRequested := false
-	emailCompleted := false
-	for _, job := range jobs {
-		if job == "email" {
-			emailRequested = true
-			break
+	for param, target := range map[string]*string{
+		"createdAfter":  &filter.CreatedAfter,
+		"createdBefore": &filter.CreatedBefore,
+	} {
+		if value := query.Get(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, param+" must be an RFC3339 timestamp")
+				return
+			}
+			*target = t.UTC().Format(time.RFC3339)
 		}
 	}
-	for _, completed := range completedJobs {
-		if completed == "email" {
-			emailCompleted = true
-			break
+
+	switch query.Get("sort") {
+	case "", "createdAt", "created_at":
+	case "updatedAt", "updated_at":
+		filter.SortBy = "updated_at"
+	default:
+		writeError(w, r, http.StatusBadRequest, "sort must be 'createdAt' or 'updatedAt'")
+		return
+	}
+
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		filter.Descending = false
+	default:
+		writeError(w, r, http.StatusBadRequest, "order must be 'asc' or 'desc'")
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxListLimit {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxListLimit))
+			return
 		}
+		filter.Limit = n
 	}
 
-	if emailRequested {
-		emailStatus := &models.JobStatus{
-			Pending: !emailCompleted,
+	if cursor := query.Get("cursor"); cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil || c.Sort != filter.SortBy || c.Descending != filter.Descending {
+			writeError(w, r, http.StatusBadRequest, "invalid cursor")
+			return
 		}
+		filter.AfterValue = c.Value
+		filter.AfterID = c.ID
+	}
 
-		// Set current provider
-		if emailProviderID != nil && *emailProviderID != "" {
-			provider, exists := h.data.GetProvider(*emailProviderID)
-			if exists {
-				emailStatus.CurrentProvider = &provider
-			}
+	// Fetch one extra row to know whether there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+
+	rows, err := h.db.ListEnrichments(filter)
+	if err != nil {
+		writeInternalError(w, r, "failed to list enrichments", err)
+		return
+	}
+
+	list := models.EnrichmentList{Items: []*models.Enrichment{}}
+	if len(rows) > pageSize {
+		last := rows[pageSize-1]
+		c := listCursor{Sort: filter.SortBy, Descending: filter.Descending, Value: last.CreatedAt, ID: last.ID}
+		if filter.SortBy == "updated_at" {
+			c.Value = last.UpdatedAt
 		}
+		list.NextCursor = encodeCursor(c)
+		rows = rows[:pageSize]
+	}
 
-		// Set result and message
-		if emailCompleted {
-			if enrichment.Result != nil && enrichment.Result.Email != "" {
-				emailStatus.Result = enrichment.Result.Email
-				emailStatus.Message = "Email found successfully"
-			} else {
-				emailStatus.Result = ""
-				emailStatus.Message = "Email not found after checking all providers"
-			}
-		} else {
-			emailStatus.Message = "Searching for email..."
+	for _, row := range rows {
+		enrichment, err := h.loadEnrichment(row.ID)
+		if err != nil {
+			writeInternalError(w, r, "failed to get enrichment", err)
+			return
 		}
+		if enrichment != nil {
+			list.Items = append(list.Items, enrichment)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
 
-		enrichment.Email = emailStatus
+// GetWebhookDeliveries godoc
+// @Summary      List webhook deliveries
+// @Description  Returns every attempt to deliver the enrichment to its callbackUrl, oldest first
+// @Tags         enrichment
+// @Accept       json
+// @Produce      json
+// @Param        enrichmentId   path      string  true  "Enrichment ID"
+// @Success      200  {array}   models.WebhookDelivery
+// @Failure      404  {object}  models.Problem
+// @Router       /enrichment/{enrichmentId}/deliveries [get]
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
 	}
 
-	writeJSON(w, http.StatusOK, enrichment)
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/enrichment/"), "/deliveries")
+	if id == "" {
+		writeError(w, r, http.StatusBadRequest, "missing enrichment ID")
+		return
+	}
+
+	enrichment, err := h.db.GetEnrichment(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get enrichment", err)
+		return
+	}
+	if enrichment == nil {
+		writeProblem(w, r, http.StatusNotFound, problem.TypeEnrichmentNotFound, "enrichment not found")
+		return
+	}
+
+	deliveries, err := h.db.GetWebhookDeliveries(id)
+	if err != nil {
+		writeInternalError(w, r, "failed to get webhook deliveries", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// GetProviders godoc
+// @Summary      Get all providers
+// @Description  Returns every provider with its configured behaviour: supported job types, credit cost, latency distribution and success rates
+// @Tags         providers
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   models.ProviderConfig
+// @Router       /providers [get]
+func (h *Handler) GetProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.data.GetAllProviderConfigs())
+}
+
+// ListScenarios godoc
+// @Summary      List scenarios
+// @Description  Returns every scenario loaded at startup, which can be referenced by name when starting an enrichment
+// @Tags         scenarios
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   models.Scenario
+// @Router       /scenarios [get]
+func (h *Handler) ListScenarios(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.data.GetAllScenarios())
 }
 
 // GetThirdPartyInfo godoc
@@ -306,30 +1150,48 @@ func (h *Handler) GetEnrichment(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        full_name   path      string  true  "Full name (URL encoded)"
+// @Param        X-Seed      header    int     false  "Seed of the artificial latency"
 // @Success      200         {object}  models.ThirdPartyInfo
-// @Failure      404         {object}  models.ErrorResponse
+// @Failure      400         {object}  models.Problem
+// @Failure      404         {object}  models.Problem
 // @Router       /thirdparty/{full_name} [get]
 func (h *Handler) GetThirdPartyInfo(w http.ResponseWriter, r *http.Request) {
+	seed, err := parseSeedHeader(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	rand := h.config.Rand
+	if seed != nil {
+		rand = rng.New(*seed)
+	}
+
 	// Add artificial latency (500ms - 2000ms) to simulate real third-party API
 	delay := 500 + rand.Intn(1500)
-	time.Sleep(time.Duration(delay) * time.Millisecond)
+	timer := h.config.Clock.NewTimer(time.Duration(delay) * time.Millisecond)
+	select {
+	case <-timer.C():
+	case <-r.Context().Done():
+		timer.Stop()
+		return
+	}
 
 	fullName := strings.TrimPrefix(r.URL.Path, "/thirdparty/")
 	if fullName == "" {
-		writeError(w, http.StatusBadRequest, "missing full name")
+		writeError(w, r, http.StatusBadRequest, "missing full name")
 		return
 	}
 
 	// URL decode the full name
 	decodedName, err := url.PathUnescape(fullName)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid full name encoding")
+		writeError(w, r, http.StatusBadRequest, "invalid full name encoding")
 		return
 	}
 
 	info, exists := h.data.GetThirdPartyInfo(decodedName)
 	if !exists {
-		writeError(w, http.StatusNotFound, "third-party information not found")
+		writeProblem(w, r, http.StatusNotFound, problem.TypeThirdPartyNotFound, "third-party information not found")
 		return
 	}
 
@@ -349,6 +1211,241 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// loadEnrichment retrieves an enrichment with its Phone and Email JobStatus objects populated
+// Returns nil if the enrichment does not exist
+func (h *Handler) loadEnrichment(id string) (*models.Enrichment, error) {
+	return h.db.GetEnrichmentDetails(id, h.data.GetProvider)
+}
+
+// idempotencyRecord returns the stored outcome for an idempotency key, or nil if the key is unused or expired
+func (h *Handler) idempotencyRecord(key string) (*database.IdempotencyRecord, error) {
+	if err := h.db.DeleteExpiredIdempotencyKeys(h.config.IdempotencyKeyTTL); err != nil {
+		return nil, err
+	}
+	return h.db.GetIdempotencyKey(key)
+}
+
+// hashRequest returns a hash of the decoded request, so formatting differences in the body do not matter
+func hashRequest(req interface{}) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// enrichmentOptions validates a start request and converts it into options for a new enrichment,
+// resolving the provider order of every job against the configured providers
+func (h *Handler) enrichmentOptions(req models.EnrichmentStartRequest) (database.EnrichmentOptions, error) {
+	if req.UserID == "" {
+		return database.EnrichmentOptions{}, fmt.Errorf("userId is required")
+	}
+
+	// Validate jobs if provided
+	var jobs []string
+	if len(req.Jobs) > 0 {
+		for _, job := range req.Jobs {
+			if job == models.JobTypePhone || job == models.JobTypeEmail {
+				jobs = append(jobs, string(job))
+			}
+		}
+		if len(jobs) == 0 {
+			return database.EnrichmentOptions{}, fmt.Errorf("jobs must contain 'phone' and/or 'email'")
+		}
+	}
+
+	if req.CallbackURL != "" && !isValidCallbackURL(req.CallbackURL) {
+		return database.EnrichmentOptions{}, fmt.Errorf("callbackUrl must be an absolute http or https URL")
+	}
+
+	switch req.Dedupe {
+	case "", models.DedupeModeOff, models.DedupeModeExisting, models.DedupeModeMissing:
+	default:
+		return database.EnrichmentOptions{}, fmt.Errorf("dedupe must be 'off', 'existing' or 'missing'")
+	}
+
+	// Jobs default to phone when none are given
+	requested := jobs
+	if len(requested) == 0 {
+		requested = []string{string(models.JobTypePhone)}
+	}
+	for job := range req.ProviderOrder {
+		if !containsAll(requested, []string{string(job)}) {
+			return database.EnrichmentOptions{}, fmt.Errorf("providerOrder.%s is set but %s is not requested", job, job)
+		}
+	}
+
+	configs := h.data.GetAllProviderConfigs()
+	providerOrder := make(map[string][]string, len(requested))
+	for _, job := range requested {
+		order, err := provider.ResolveOrder(configs, models.JobType(job), req.ProviderOrder[models.JobType(job)])
+		if err != nil {
+			return database.EnrichmentOptions{}, fmt.Errorf("providerOrder.%s: %w", job, err)
+		}
+		providerOrder[job] = order
+	}
+
+	// Requests without a seed get one from drawSeed once they are known to start a new enrichment
+	if req.Seed != nil {
+		if err := validateSeed(*req.Seed); err != nil {
+			return database.EnrichmentOptions{}, err
+		}
+	}
+
+	var resolved *models.Scenario
+	if req.Scenario != nil {
+		var err error
+		resolved, err = h.resolveScenario(*req.Scenario, requested, providerOrder)
+		if err != nil {
+			return database.EnrichmentOptions{}, err
+		}
+	}
+
+	return database.EnrichmentOptions{
+		UserID:        req.UserID,
+		Jobs:          jobs,
+		ContactInfo:   req.Contact,
+		CallbackURL:   req.CallbackURL,
+		ProviderOrder: providerOrder,
+		Seed:          req.Seed,
+		Scenario:      resolved,
+	}, nil
+}
+
+// drawSeed gives options without a seed a random one, so any run can be replayed by starting it again
+// with the same seed. It is called right before the enrichment is created, so replayed and deduplicated
+// starts do not advance the seed sequence.
+func (h *Handler) drawSeed(opts *database.EnrichmentOptions) {
+	if opts.Seed == nil {
+		seed := h.config.Rand.Seed()
+		opts.Seed = &seed
+	}
+}
+
+// resolveScenario looks up or validates the scenario of a start request and keeps the steps of the requested jobs.
+// Every job must have enough providers for its steps.
+func (h *Handler) resolveScenario(ref models.ScenarioRef, requested []string, providerOrder map[string][]string) (*models.Scenario, error) {
+	var resolved models.Scenario
+	if ref.Inline != nil {
+		resolved = *ref.Inline
+		if resolved.Name == "" {
+			resolved.Name = "inline"
+		}
+		if err := scenario.Validate(resolved); err != nil {
+			return nil, fmt.Errorf("scenario: %w", err)
+		}
+	} else {
+		named, exists := h.data.GetScenario(ref.Name)
+		if !exists {
+			return nil, fmt.Errorf("unknown scenario %s", ref.Name)
+		}
+		resolved = named
+	}
+
+	jobs := make(map[models.JobType][]models.ScenarioStep, len(requested))
+	findsEveryJob := true
+	for _, job := range requested {
+		steps := resolved.Jobs[models.JobType(job)]
+		if len(steps) > len(providerOrder[job]) {
+			return nil, fmt.Errorf("scenario %s has %d steps for %s but only %d providers look it up", resolved.Name, len(steps), job, len(providerOrder[job]))
+		}
+		jobs[models.JobType(job)] = steps
+		if !scenario.Finds(steps) {
+			findsEveryJob = false
+		}
+	}
+	if resolved.Status == models.EnrichmentStatusFailed && findsEveryJob {
+		return nil, fmt.Errorf("scenario %s fails the enrichment but finds every requested job", resolved.Name)
+	}
+	resolved.Jobs = jobs
+
+	return &resolved, nil
+}
+
+// parseSeedHeader returns the seed sent in the X-Seed header, or nil if there is none
+func parseSeedHeader(r *http.Request) (*int64, error) {
+	raw := r.Header.Get(seedHeader)
+	if raw == "" {
+		return nil, nil
+	}
+	seed, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s header must be an integer", seedHeader)
+	}
+	if err := validateSeed(seed); err != nil {
+		return nil, err
+	}
+	return &seed, nil
+}
+
+// validateSeed checks that a seed survives a round trip through JSON clients
+func validateSeed(seed int64) error {
+	if seed < 0 || seed >= rng.MaxSeed {
+		return fmt.Errorf("seed must be between 0 and %d", int64(rng.MaxSeed-1))
+	}
+	return nil
+}
+
+// containsAll reports whether every job in want is in have
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// toJobTypes converts stored job names to job types
+func toJobTypes(jobs []string) []models.JobType {
+	types := make([]models.JobType, len(jobs))
+	for i, job := range jobs {
+		types[i] = models.JobType(job)
+	}
+	return types
+}
+
+// isValidCallbackURL checks that a callback URL is an absolute http(s) URL
+func isValidCallbackURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// listCursor is the position after which the next page of a listing starts
+type listCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Value      string `json:"v"`
+	ID         string `json:"id"`
+}
+
+// encodeCursor encodes a cursor into an opaque URL-safe string
+func encodeCursor(c listCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor decodes a cursor produced by encodeCursor
+func decodeCursor(s string) (listCursor, error) {
+	var c listCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	if c.ID == "" {
+		return c, fmt.Errorf("cursor is missing an ID")
+	}
+	return c, nil
+}
+
 // writeJSON writes a JSON response
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -356,11 +1453,36 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// writeError writes an error response
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, models.ErrorResponse{
-		Error:   http.StatusText(status),
-		Code:    status,
-		Message: message,
-	})
+// writeRawJSON writes an already encoded JSON response
+func writeRawJSON(w http.ResponseWriter, status int, data []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
+
+// writeError writes a problem details response whose type is derived from the status
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	problem.Write(w, r, status, "", message, nil)
+}
+
+// writeInternalError logs an unexpected error with the request's trace ID and writes a 500 with a fixed
+// message, so internal details never reach clients
+func writeInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	log.Printf("Error handling %s %s (trace %s): %s: %v", r.Method, r.URL.Path, problem.TraceID(r), message, err)
+	writeError(w, r, http.StatusInternalServerError, message)
+}
+
+// writeProblem writes a problem details response of a specific problem type
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, problemType, message string) {
+	problem.Write(w, r, status, problemType, message, nil)
+}
+
+// writeValidationError writes a 400 listing every invalid field, joining their messages as the detail
+func writeValidationError(w http.ResponseWriter, r *http.Request, fieldErrors []models.FieldError) {
+	messages := make([]string, len(fieldErrors))
+	for i, e := range fieldErrors {
+		messages[i] = e.Message
+	}
+	problem.Write(w, r, http.StatusBadRequest, problem.TypeValidation, strings.Join(messages, "; "), fieldErrors)
 }
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []listCursor{
+		{Sort: "created_at", Value: "2024-01-15T10:00:00Z", ID: "a1b2c3d4"},
+		{Sort: "updated_at", Descending: true, Value: "2024-01-15T10:00:00Z", ID: "a1b2c3d4"},
+		{Sort: "name", Value: "Doe John", ID: "contact-1"},
+		{Sort: "email", Value: "", ID: "contact-2"},
+		{Sort: "name", Value: "Ünïcødé / + = & ?", ID: "contact-3"},
+	}
+
+	for _, want := range tests {
+		encoded := encodeCursor(want)
+		if _, err := base64.RawURLEncoding.DecodeString(encoded); err != nil {
+			t.Errorf("encodeCursor(%+v) = %q, not URL-safe base64: %v", want, encoded, err)
+		}
+		got, err := decodeCursor(encoded)
+		if err != nil {
+			t.Fatalf("decodeCursor(encodeCursor(%+v)) error = %v", want, err)
+		}
+		if got != want {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", want, got)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "not a cursor!"},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte(`{"s":"name","v":"x","id":"1"}`))},
+		{name: "not JSON", cursor: encode("name:x:1")},
+		{name: "wrong JSON type", cursor: encode(`["name","x","1"]`)},
+		{name: "missing ID", cursor: encode(`{"s":"name","v":"x"}`)},
+		{name: "empty ID", cursor: encode(`{"s":"name","v":"x","id":""}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := decodeCursor(tt.cursor); err == nil {
+				t.Errorf("decodeCursor(%q) = %+v, want an error", tt.cursor, c)
+			}
+		})
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{header: `"3"`, want: true},
+		{header: `"3"`, weak: true, want: true},
+		{header: `"4"`, want: false},
+		{header: `3`, want: false},
+		{header: `""`, want: false},
+		{header: ``, want: false},
+		{header: `*`, want: true},
+		{header: ` * `, weak: true, want: true},
+		{header: `"1", "2", "3"`, want: true},
+		{header: `"1","3"`, weak: true, want: true},
+		{header: `"1", "2"`, want: false},
+		{header: `W/"3"`, want: false},
+		{header: `W/"3"`, weak: true, want: true},
+		{header: `W/"4", "3"`, want: true},
+		{header: `W/"4"`, weak: true, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"3"`, tt.weak); got != tt.want {
+			t.Errorf("etagMatches(%q, %q, %v) = %v, want %v", tt.header, `"3"`, tt.weak, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/surfe/mock-api/internal/events"
+	"github.com/surfe/mock-api/internal/models"
+)
+
+const (
+	// wsWriteWait is how long a single write to the socket may take
+	wsWriteWait = 10 * time.Second
+
+	// wsPongWait is how long the connection may stay silent before it is considered dead
+	wsPongWait = 60 * time.Second
+
+	// wsPingPeriod is how often pings are sent, must be shorter than wsPongWait
+	wsPingPeriod = (wsPongWait * 9) / 10
+
+	// wsMaxMessageSize is the largest message accepted from a client
+	wsMaxMessageSize = 4096
+
+	// wsSendBuffer is how many messages may queue up for a client before it is disconnected as too slow
+	wsSendBuffer = 64
+)
+
+// wsUpgrader accepts connections from any origin, matching the CORS policy
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsClient is a single WebSocket connection and its subscriptions
+type wsClient struct {
+	h    *Handler
+	conn *websocket.Conn
+	send chan models.WebSocketMessage
+	done chan struct{}
+	once sync.Once
+
+	mu            sync.Mutex
+	enrichmentIDs map[string]bool
+	userIDs       map[string]bool
+}
+
+// EnrichmentWebSocket godoc
+// @Summary      Watch many enrichments over one WebSocket
+// @Description  Upgrades to a WebSocket. Send {"action":"subscribe","enrichmentIds":[...]} or {"action":"subscribe","userId":"..."} to watch enrichments, "unsubscribe" to stop and "ping" to get a "pong". Every change to a watched enrichment is pushed as {"type":"enrichment","event":"...","enrichment":{...}} with the same payload as GET /enrichment/{id}
+// @Tags         enrichment
+// @Param        request  body  models.WebSocketRequest  false  "Client message"
+// @Success      101  {object}  models.WebSocketMessage
+// @Router       /ws [get]
+func (h *Handler) EnrichmentWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an HTTP error
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	c := &wsClient{
+		h:             h,
+		conn:          conn,
+		send:          make(chan models.WebSocketMessage, wsSendBuffer),
+		done:          make(chan struct{}),
+		enrichmentIDs: make(map[string]bool),
+		userIDs:       make(map[string]bool),
+	}
+
+	sub := h.events.Subscribe()
+
+	go c.writePump()
+	go c.forwardEvents(sub)
+
+	// Block until the client goes away, then drop every subscription
+	c.readPump()
+	sub.Close()
+	c.close()
+}
+
+// readPump handles messages from the client until the connection fails
+func (c *wsClient) readPump() {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("WebSocket closed unexpectedly: %v", err)
+			}
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var req models.WebSocketRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.enqueue(models.WebSocketMessage{Type: "error", Message: "invalid message"})
+			continue
+		}
+
+		switch req.Action {
+		case "subscribe":
+			c.subscribe(req)
+		case "unsubscribe":
+			c.unsubscribe(req)
+		case "ping":
+			c.enqueue(models.WebSocketMessage{Type: "pong"})
+		default:
+			c.enqueue(models.WebSocketMessage{Type: "error", Message: "action must be 'subscribe', 'unsubscribe' or 'ping'"})
+		}
+	}
+}
+
+// writePump writes queued messages and keepalive pings to the socket
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	defer c.conn.Close()
+
+	for {
+		select {
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+	}
+}
+
+// forwardEvents pushes the current enrichment to the client for every event it is subscribed to
+func (c *wsClient) forwardEvents(sub *events.Subscription) {
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if !c.watches(e) {
+				continue
+			}
+			enrichment, err := c.h.loadEnrichment(e.EnrichmentID)
+			if err != nil || enrichment == nil {
+				continue
+			}
+			c.enqueue(models.WebSocketMessage{Type: "enrichment", Event: string(e.Type), Enrichment: enrichment})
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// subscribe adds subscriptions and sends the current state of each watched enrichment
+func (c *wsClient) subscribe(req models.WebSocketRequest) {
+	if len(req.EnrichmentIDs) == 0 && req.UserID == "" {
+		c.enqueue(models.WebSocketMessage{Type: "error", Message: "enrichmentIds or userId is required"})
+		return
+	}
+
+	var snapshots []*models.Enrichment
+	for _, id := range req.EnrichmentIDs {
+		enrichment, err := c.h.loadEnrichment(id)
+		if err != nil || enrichment == nil {
+			c.enqueue(models.WebSocketMessage{Type: "error", Message: "enrichment not found: " + id})
+			continue
+		}
+		snapshots = append(snapshots, enrichment)
+	}
+
+	c.mu.Lock()
+	for _, enrichment := range snapshots {
+		c.enrichmentIDs[enrichment.ID] = true
+	}
+	if req.UserID != "" {
+		c.userIDs[req.UserID] = true
+	}
+	c.mu.Unlock()
+
+	c.enqueue(c.subscriptions("subscribed"))
+	for _, enrichment := range snapshots {
+		c.enqueue(models.WebSocketMessage{Type: "enrichment", Event: "snapshot", Enrichment: enrichment})
+	}
+}
+
+// unsubscribe removes subscriptions
+func (c *wsClient) unsubscribe(req models.WebSocketRequest) {
+	c.mu.Lock()
+	for _, id := range req.EnrichmentIDs {
+		delete(c.enrichmentIDs, id)
+	}
+	if req.UserID != "" {
+		delete(c.userIDs, req.UserID)
+	}
+	c.mu.Unlock()
+
+	c.enqueue(c.subscriptions("unsubscribed"))
+}
+
+// subscriptions builds a message listing the current subscriptions
+func (c *wsClient) subscriptions(messageType string) models.WebSocketMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg := models.WebSocketMessage{Type: messageType, EnrichmentIDs: []string{}, UserIDs: []string{}}
+	for id := range c.enrichmentIDs {
+		msg.EnrichmentIDs = append(msg.EnrichmentIDs, id)
+	}
+	for id := range c.userIDs {
+		msg.UserIDs = append(msg.UserIDs, id)
+	}
+	sort.Strings(msg.EnrichmentIDs)
+	sort.Strings(msg.UserIDs)
+	return msg
+}
+
+// watches reports whether the client is subscribed to the event's enrichment or user
+func (c *wsClient) watches(e events.Event) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enrichmentIDs[e.EnrichmentID] || (e.UserID != "" && c.userIDs[e.UserID])
+}
+
+// enqueue queues a message for the client. A client that cannot keep up is disconnected
+// rather than letting messages pile up in memory.
+func (c *wsClient) enqueue(msg models.WebSocketMessage) {
+	select {
+	case c.send <- msg:
+	case <-c.done:
+	default:
+		log.Printf("WebSocket client too slow, closing connection")
+		c.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow"),
+			time.Now().Add(wsWriteWait))
+		c.close()
+		c.conn.Close()
+	}
+}
+
+// close signals every goroutine of the client to stop
+func (c *wsClient) close() {
+	c.once.Do(func() { close(c.done) })
+}
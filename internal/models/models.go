@@ -1,14 +1,21 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // Contact represents basic contact information
 type Contact struct {
 	ID        string `json:"id"`
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName"`
 	Email     string `json:"email"`
-	Phone     string `json:"phone"`
+	Phone     string `json:"phone"`               // The phone as it was entered or found, e.g. "+1-555-123-4567"
+	PhoneE164 string `json:"phoneE164,omitempty"` // The phone in E.164 form, e.g. "+15551234567"
 	Company   string `json:"company,omitempty"`
 	JobTitle  string `json:"jobTitle,omitempty"`
+	Version   int    `json:"version,omitempty"` // Bumped by every change, served as the ETag of the contact
 }
 
 // EnrichmentStatus represents the possible states of an enrichment
@@ -19,32 +26,78 @@ const (
 	EnrichmentStatusInProgress EnrichmentStatus = "in_progress"
 	EnrichmentStatusCompleted  EnrichmentStatus = "completed"
 	EnrichmentStatusFailed     EnrichmentStatus = "failed"
+	EnrichmentStatusCancelled  EnrichmentStatus = "cancelled"
 )
 
+// IsTerminal reports whether the status is final and the enrichment will not change anymore
+func (s EnrichmentStatus) IsTerminal() bool {
+	return s == EnrichmentStatusCompleted || s == EnrichmentStatusFailed || s == EnrichmentStatusCancelled
+}
+
 // JobStatus represents the status of a specific job (phone or email)
 type JobStatus struct {
-	CurrentProvider *Provider `json:"currentProvider,omitempty"`
-	Result          string    `json:"result,omitempty"`
-	Message         string    `json:"message,omitempty"`
-	Pending         bool      `json:"pending"`
+	CurrentProvider *Provider         `json:"currentProvider,omitempty"`
+	Result          string            `json:"result,omitempty"`
+	Message         string            `json:"message,omitempty"`
+	Pending         bool              `json:"pending"`
+	ProviderOrder   []Provider        `json:"providerOrder,omitempty"` // Providers the job walks through, in order
+	Attempts        []ProviderAttempt `json:"attempts"`                // Every provider tried so far, oldest first
+	FoundBy         *Provider         `json:"foundBy,omitempty"`       // Provider that found the result
+}
+
+// AttemptOutcome is the result of a single provider lookup
+type AttemptOutcome string
+
+const (
+	AttemptOutcomeInProgress AttemptOutcome = "in_progress"
+	AttemptOutcomeFound      AttemptOutcome = "found"
+	AttemptOutcomeNotFound   AttemptOutcome = "not_found"
+	AttemptOutcomeError      AttemptOutcome = "error"
+	AttemptOutcomeCancelled  AttemptOutcome = "cancelled"
+)
+
+// ProviderAttempt records one provider lookup for a job
+type ProviderAttempt struct {
+	ID         string         `json:"id"`
+	Job        JobType        `json:"job"`
+	ProviderID string         `json:"providerId"`
+	Provider   *Provider      `json:"provider,omitempty"`
+	Outcome    AttemptOutcome `json:"outcome"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  string         `json:"startedAt"`
+	FinishedAt string         `json:"finishedAt,omitempty"`
+	DurationMs int64          `json:"durationMs,omitempty"`
 }
 
 // Enrichment represents an enrichment process
 type Enrichment struct {
-	ID        string            `json:"id"`
-	UserID    string            `json:"userId"`
-	Status    EnrichmentStatus  `json:"status"`
-	CreatedAt string            `json:"createdAt"`
-	UpdatedAt string            `json:"updatedAt"`
-	Result    *EnrichmentResult `json:"result,omitempty"`
-	Phone     *JobStatus        `json:"phone,omitempty"`
-	Email     *JobStatus        `json:"email,omitempty"`
+	ID          string            `json:"id"`
+	UserID      string            `json:"userId"`
+	Status      EnrichmentStatus  `json:"status"`
+	CreatedAt   string            `json:"createdAt"`
+	UpdatedAt   string            `json:"updatedAt"`
+	RetryOf     string            `json:"retryOf,omitempty"`     // ID of the enrichment this one retries
+	CallbackURL string            `json:"callbackUrl,omitempty"` // URL that receives the final enrichment
+	BatchID     string            `json:"batchId,omitempty"`     // ID of the bulk batch this enrichment belongs to
+	Seed        *int64            `json:"seed,omitempty"`        // Seed of the random decisions, start another enrichment with it to replay them
+	Scenario    string            `json:"scenario,omitempty"`    // Name of the scenario the enrichment replays
+	Error       string            `json:"error,omitempty"`       // Why the enrichment failed, when it did not fail on a provider
+	Result      *EnrichmentResult `json:"result,omitempty"`
+	Phone       *JobStatus        `json:"phone,omitempty"`
+	Email       *JobStatus        `json:"email,omitempty"`
+}
+
+// EnrichmentList is a page of enrichments
+type EnrichmentList struct {
+	Items      []*Enrichment `json:"items"`
+	NextCursor string        `json:"nextCursor,omitempty"` // Pass as cursor to fetch the next page
 }
 
 // EnrichmentResult contains the enriched data
 type EnrichmentResult struct {
-	Phone string `json:"phone,omitempty"`
-	Email string `json:"email,omitempty"`
+	Phone     string `json:"phone,omitempty"`     // The phone as the provider returned it
+	PhoneE164 string `json:"phoneE164,omitempty"` // The phone in E.164 form, e.g. "+15551234567"
+	Email     string `json:"email,omitempty"`
 }
 
 // EnrichmentContactInfo contains optional third-party contact information
@@ -69,16 +122,179 @@ const (
 
 // EnrichmentStartRequest is the payload for starting an enrichment
 type EnrichmentStartRequest struct {
-	UserID  string                 `json:"userId"`
-	Jobs    []JobType              `json:"jobs,omitempty"`    // Array of "email" and/or "phone"
-	Contact *EnrichmentContactInfo `json:"contact,omitempty"` // Optional contact info to boost success rate
+	UserID      string                 `json:"userId"`
+	Jobs        []JobType              `json:"jobs,omitempty"`        // Array of "email" and/or "phone"
+	Contact     *EnrichmentContactInfo `json:"contact,omitempty"`     // Optional contact info to boost success rate
+	CallbackURL string                 `json:"callbackUrl,omitempty"` // Optional URL that receives the final enrichment
+	Dedupe      DedupeMode             `json:"dedupe,omitempty"`      // What to do when the user already has a running enrichment, defaults to "off"
+	// ProviderOrder picks the order in which each job walks through the providers
+	ProviderOrder map[JobType]ProviderOrder `json:"providerOrder,omitempty"`
+	// Seed drives the provider delays and outcomes; the same seed always yields the same enrichment
+	Seed *int64 `json:"seed,omitempty"`
+	// Scenario replays scripted provider outcomes instead of rolling dice, given by name or inline
+	Scenario *ScenarioRef `json:"scenario,omitempty" swaggertype:"object"`
+}
+
+// Scenario dictates what happens to an enrichment: each job walks through its providers in order
+// and the n-th provider answers with the n-th step; providers without a step miss immediately
+type Scenario struct {
+	Name        string                     `json:"name"`
+	Description string                     `json:"description,omitempty"`
+	Jobs        map[JobType][]ScenarioStep `json:"jobs"`
+	// Status the enrichment ends with once its jobs are done, "completed" (default) or "failed"
+	Status EnrichmentStatus `json:"status,omitempty"`
+}
+
+// ScenarioStep is the answer of one provider in a scenario
+type ScenarioStep struct {
+	Outcome AttemptOutcome `json:"outcome"`           // "found", "not_found" or "error"
+	DelayMs int            `json:"delayMs,omitempty"` // How long the provider takes to answer
+	Value   string         `json:"value,omitempty"`   // Value found, defaults to the contact's mock enrichment data
+	Error   string         `json:"error,omitempty"`   // Error message of an "error" step
+}
+
+// ScenarioRef references a loaded scenario by name, or holds an inline scenario.
+// It is a JSON string for a name and a JSON object for an inline scenario.
+type ScenarioRef struct {
+	Name   string
+	Inline *Scenario
+}
+
+// UnmarshalJSON reads a scenario name or an inline scenario
+func (r *ScenarioRef) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*r = ScenarioRef{Name: name}
+		return nil
+	}
+	var inline Scenario
+	if err := json.Unmarshal(data, &inline); err != nil {
+		return fmt.Errorf("scenario must be a name or a scenario object: %w", err)
+	}
+	*r = ScenarioRef{Inline: &inline}
+	return nil
+}
+
+// MarshalJSON writes the scenario name, or the inline scenario
+func (r ScenarioRef) MarshalJSON() ([]byte, error) {
+	if r.Inline != nil {
+		return json.Marshal(r.Inline)
+	}
+	return json.Marshal(r.Name)
+}
+
+// ProviderOrder selects the providers of a job and their order, either explicitly or through a strategy
+type ProviderOrder struct {
+	Strategy  ProviderStrategy `json:"strategy,omitempty"`  // Ignored when providers is set
+	Providers []string         `json:"providers,omitempty"` // Provider IDs, tried in this order; providers left out are skipped
+}
+
+// ProviderStrategy orders all providers supporting a job
+type ProviderStrategy string
+
+const (
+	// ProviderStrategyDefault tries providers in alphabetical order of their name
+	ProviderStrategyDefault ProviderStrategy = "default"
+	// ProviderStrategyCheapestFirst tries the provider with the lowest credit cost first
+	ProviderStrategyCheapestFirst ProviderStrategy = "cheapest_first"
+	// ProviderStrategyHighestHitRateFirst tries the provider most likely to find the value first
+	ProviderStrategyHighestHitRateFirst ProviderStrategy = "highest_hit_rate_first"
+)
+
+// DedupeMode selects how a start request treats enrichments already running for the same user
+type DedupeMode string
+
+const (
+	// DedupeModeOff always starts a new enrichment
+	DedupeModeOff DedupeMode = "off"
+	// DedupeModeExisting returns a running enrichment that covers every requested job instead of starting a new one
+	DedupeModeExisting DedupeMode = "existing"
+	// DedupeModeMissing starts a new enrichment only for the jobs no running enrichment covers
+	DedupeModeMissing DedupeMode = "missing"
+)
+
+// BulkEnrichmentRequest starts many enrichments at once, either as individual items
+// or as a list of user IDs sharing the same jobs
+type BulkEnrichmentRequest struct {
+	Items       []EnrichmentStartRequest `json:"items,omitempty"`
+	UserIDs     []string                 `json:"userIds,omitempty"`
+	Jobs        []JobType                `json:"jobs,omitempty"`        // Jobs shared by every entry of userIds
+	CallbackURL string                   `json:"callbackUrl,omitempty"` // Callback shared by every entry of userIds
+	// ProviderOrder shared by every entry of userIds
+	ProviderOrder map[JobType]ProviderOrder `json:"providerOrder,omitempty"`
+	// Scenario shared by every entry of userIds
+	Scenario *ScenarioRef `json:"scenario,omitempty" swaggertype:"object"`
+	// Seed from which every entry of userIds derives its own seed
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// BulkEnrichmentResponse is returned when a batch of enrichments is started
+type BulkEnrichmentResponse struct {
+	BatchID       string   `json:"batchId"`
+	EnrichmentIDs []string `json:"enrichmentIds"`
+	Message       string   `json:"message"`
+}
+
+// BatchStatus reports the aggregate progress of a batch of enrichments
+type BatchStatus struct {
+	ID            string                        `json:"id"`
+	CreatedAt     string                        `json:"createdAt"`
+	Total         int                           `json:"total"`
+	Done          bool                          `json:"done"` // True once every enrichment reached a final status
+	StatusCounts  map[EnrichmentStatus]int      `json:"statusCounts"`
+	Jobs          map[JobType]*BatchJobProgress `json:"jobs"`
+	EnrichmentIDs []string                      `json:"enrichmentIds"`
+}
+
+// BatchJobProgress counts the outcome of one job type across a batch
+type BatchJobProgress struct {
+	Requested int `json:"requested"`
+	Found     int `json:"found"`
+	NotFound  int `json:"notFound"`
+	Pending   int `json:"pending"`
 }
 
 // EnrichmentStartResponse is returned when an enrichment is started
 type EnrichmentStartResponse struct {
-	ID      string           `json:"id"`
-	Status  EnrichmentStatus `json:"status"`
-	Message string           `json:"message"`
+	ID                  string           `json:"id"`
+	Status              EnrichmentStatus `json:"status"`
+	Message             string           `json:"message"`
+	RetryOf             string           `json:"retryOf,omitempty"`
+	Jobs                []JobType        `json:"jobs,omitempty"`                // Jobs the returned enrichment works on
+	Seed                *int64           `json:"seed,omitempty"`                // Seed of the started enrichment
+	Deduplicated        bool             `json:"deduplicated,omitempty"`        // True when the request was served by already running enrichments
+	ActiveEnrichmentIDs []string         `json:"activeEnrichmentIds,omitempty"` // Running enrichments that already cover some of the requested jobs
+}
+
+// WebSocketRequest is a message sent by a client over the /ws endpoint
+type WebSocketRequest struct {
+	Action        string   `json:"action"`                  // "subscribe", "unsubscribe" or "ping"
+	EnrichmentIDs []string `json:"enrichmentIds,omitempty"` // Enrichments to (un)subscribe to
+	UserID        string   `json:"userId,omitempty"`        // (Un)subscribe to every enrichment of this user
+}
+
+// WebSocketMessage is a message sent by the server over the /ws endpoint
+type WebSocketMessage struct {
+	Type          string      `json:"type"`                    // "enrichment", "subscribed", "unsubscribed", "pong" or "error"
+	Event         string      `json:"event,omitempty"`         // Event that triggered an "enrichment" message
+	Enrichment    *Enrichment `json:"enrichment,omitempty"`    // Current state of the enrichment
+	EnrichmentIDs []string    `json:"enrichmentIds,omitempty"` // Current enrichment subscriptions
+	UserIDs       []string    `json:"userIds,omitempty"`       // Current user subscriptions
+	Message       string      `json:"message,omitempty"`
+}
+
+// WebhookDelivery records a single attempt to POST a finished enrichment to its callback URL
+type WebhookDelivery struct {
+	ID           string `json:"id"`
+	EnrichmentID string `json:"enrichmentId"`
+	Event        string `json:"event"` // "enrichment.completed" or "enrichment.failed"
+	URL          string `json:"url"`
+	Attempt      int    `json:"attempt"`
+	StatusCode   int    `json:"statusCode,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Success      bool   `json:"success"`
+	DurationMs   int64  `json:"durationMs"`
+	CreatedAt    string `json:"createdAt"`
 }
 
 // ThirdPartyInfo represents additional information from third-party sources
@@ -99,13 +315,128 @@ type Provider struct {
 	ImageURL string `json:"imageUrl,omitempty"`
 }
 
-// ErrorResponse represents an API error
+// ProviderConfig describes how a mock provider behaves
+type ProviderConfig struct {
+	Provider
+	Jobs               []JobType           `json:"jobs"`                         // Job types the provider can look up
+	CreditCost         int                 `json:"creditCost"`                   // Credits charged per lookup
+	Latency            LatencyDistribution `json:"latency"`                      // How long a lookup takes
+	SuccessRate        map[JobType]float32 `json:"successRate"`                  // Probability (0.0 to 1.0) of finding each job's value
+	MatchedSuccessRate float32             `json:"matchedSuccessRate,omitempty"` // Success rate when the contact info matches the third-party data
+}
+
+// Supports reports whether the provider can look up the given job type
+func (c ProviderConfig) Supports(job JobType) bool {
+	for _, j := range c.Jobs {
+		if j == job {
+			return true
+		}
+	}
+	return false
+}
+
+// LatencyDistribution describes how long a provider lookup takes
+type LatencyDistribution struct {
+	Distribution string `json:"distribution"`       // "uniform" between minMs and maxMs, or "normal" around meanMs
+	MinMs        int    `json:"minMs"`              // Shortest lookup
+	MaxMs        int    `json:"maxMs"`              // Longest lookup
+	MeanMs       int    `json:"meanMs,omitempty"`   // Average lookup, for the normal distribution
+	StdDevMs     int    `json:"stdDevMs,omitempty"` // Standard deviation, for the normal distribution
+}
+
+// ClockState describes the virtual clock driving the enrichment lifecycle
+type ClockState struct {
+	Now           string   `json:"now"`           // Current virtual time
+	Speed         float64  `json:"speed"`         // Virtual seconds per real second, 0 when paused
+	PendingTimers []string `json:"pendingTimers"` // When each scheduled wake-up (worker poll, provider answer) is due, earliest first
+}
+
+// ClockSpeedRequest changes the speed of the virtual clock
+type ClockSpeedRequest struct {
+	Speed *float64 `json:"speed"` // Virtual seconds per real second, 0 pauses the clock
+}
+
+// ClockAdvanceRequest moves the virtual clock forward, either by a duration or to the next pending timer
+type ClockAdvanceRequest struct {
+	Duration string `json:"duration,omitempty"` // Go duration such as "5s" or "1m30s"
+	Next     bool   `json:"next,omitempty"`     // Jump to the next pending timer instead
+}
+
+// FaultType is a kind of failure injected into HTTP responses
+type FaultType string
+
+const (
+	// FaultTypeLatency delays the response by latencyMs
+	FaultTypeLatency FaultType = "latency"
+	// FaultTypeError replies with the status code instead of calling the endpoint
+	FaultTypeError FaultType = "error"
+	// FaultTypeTimeout never replies and drops the connection once the client gives up
+	FaultTypeTimeout FaultType = "timeout"
+	// FaultTypeDrop closes the connection without a response
+	FaultTypeDrop FaultType = "drop"
+	// FaultTypeTruncate cuts the response body in half, leaving malformed JSON
+	FaultTypeTruncate FaultType = "truncate"
+)
+
+// FaultRule injects a failure into the responses of matching routes
+type FaultRule struct {
+	Method      string    `json:"method,omitempty"`      // HTTP method to match, any when empty
+	Path        string    `json:"path,omitempty"`        // Route pattern such as "/enrichment/*", any when empty
+	Type        FaultType `json:"type"`                  // "latency", "error", "timeout", "drop" or "truncate"
+	Probability *float64  `json:"probability,omitempty"` // Chance (0.0 to 1.0) of injecting the fault, defaults to 1
+	LatencyMs   int       `json:"latencyMs,omitempty"`   // Delay of a "latency" fault
+	Status      int       `json:"status,omitempty"`      // Status code of an "error" fault, defaults to 500
+}
+
+// SnapshotRequest names a snapshot of the contacts and enrichments
+type SnapshotRequest struct {
+	Name string `json:"name"` // Letters, digits, '-', '_' and '.'; taking a snapshot under an existing name replaces it
+}
+
+// SnapshotInfo describes a named snapshot of the contacts and enrichments
+type SnapshotInfo struct {
+	Name        string `json:"name"`
+	CreatedAt   string `json:"createdAt"`
+	Contacts    int    `json:"contacts"`
+	Enrichments int    `json:"enrichments"`
+}
+
+// Problem is an RFC 7807 problem details error response, served as application/problem+json
+type Problem struct {
+	Type     string       `json:"type"`     // Stable identifier of the cause, e.g. "/problems/contact-not-found"
+	Title    string       `json:"title"`    // Status text of the status code
+	Status   int          `json:"status"`   // HTTP status code
+	Detail   string       `json:"detail"`   // Human-readable explanation of this occurrence
+	Instance string       `json:"instance"` // Path of the request that failed
+	TraceID  string       `json:"traceId"`  // Also sent as the X-Trace-Id header and logged with the request
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// ErrorResponse represents an API error in the shape served before problem details, kept for LEGACY_ERRORS
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    int    `json:"code"`
+	Error   string       `json:"error"`
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"` // Every invalid field of a rejected request body
+}
+
+// FieldError describes why a field of a request body was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"` // required, email, phone, phone_country_code or phone_length
 	Message string `json:"message"`
 }
 
+// CreateContactRequest is the payload for creating a contact
+type CreateContactRequest struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Email     string `json:"email,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+	Company   string `json:"company,omitempty"`
+	JobTitle  string `json:"jobTitle,omitempty"`
+}
+
 // UpdateContactRequest is the payload for updating a contact's phone and/or email
 type UpdateContactRequest struct {
 	Phone *string `json:"phone,omitempty"`
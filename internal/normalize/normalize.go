@@ -0,0 +1,164 @@
+package normalize
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	"github.com/surfe/mock-api/internal/models"
+)
+
+// Rules reported by Error, so clients can tell why a value was rejected
+const (
+	RuleRequired         = "required"
+	RuleEmail            = "email"
+	RulePhone            = "phone"
+	RulePhoneCountryCode = "phone_country_code"
+	RulePhoneLength      = "phone_length"
+)
+
+const (
+	// minPhoneDigits is the fewest digits, country code included, of a phone number
+	minPhoneDigits = 8
+	// maxPhoneDigits is the most digits E.164 allows, country code included
+	maxPhoneDigits = 15
+	// maxEmailLength is the longest address SMTP can deliver to
+	maxEmailLength = 254
+)
+
+// Error is a value that broke a validation rule
+type Error struct {
+	Rule    string
+	Message string // what is wrong, without naming the field, e.g. "must have between 8 and 15 digits"
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Phone is a phone number in E.164 form, along with the form it was given in
+type Phone struct {
+	E164    string // e.g. "+15551234567"
+	Display string // e.g. "+1 (555) 123-4567"
+}
+
+// ParsePhone validates a phone number and converts it to E.164.
+// The number may contain spaces, dashes, dots and parentheses, and must start with + or 00 and its
+// country code, except for 10-digit North American numbers, which get +1.
+func ParsePhone(s string) (Phone, error) {
+	display := strings.TrimSpace(s)
+	if display == "" {
+		return Phone{}, &Error{Rule: RuleRequired, Message: "must not be empty"}
+	}
+
+	var digits strings.Builder
+	international := false
+	for i, r := range display {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			international = true
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return Phone{}, &Error{Rule: RulePhone, Message: "may only contain digits, spaces, '-', '.', '(', ')' and a leading '+'"}
+		}
+	}
+
+	number := digits.String()
+	switch {
+	case international:
+	case strings.HasPrefix(number, "00"):
+		number = strings.TrimPrefix(number, "00")
+	case len(number) == 10:
+		number = "1" + number
+	case len(number) == 11 && number[0] == '1':
+	default:
+		return Phone{}, &Error{Rule: RulePhoneCountryCode, Message: "must start with '+' and the country code, unless it is a 10-digit North American number"}
+	}
+
+	if number == "" || number[0] == '0' {
+		return Phone{}, &Error{Rule: RulePhoneCountryCode, Message: "country code must not start with 0"}
+	}
+	if len(number) < minPhoneDigits || len(number) > maxPhoneDigits {
+		return Phone{}, &Error{Rule: RulePhoneLength, Message: "must have between 8 and 15 digits, country code included"}
+	}
+	if number[0] == '1' && len(number) != 11 {
+		return Phone{}, &Error{Rule: RulePhoneLength, Message: "must have 10 digits after the +1 country code"}
+	}
+
+	return Phone{E164: "+" + number, Display: display}, nil
+}
+
+// Email validates a plain email address such as "jane@example.com" and lowercases its domain
+func Email(s string) (string, error) {
+	address := strings.TrimSpace(s)
+	if address == "" {
+		return "", &Error{Rule: RuleRequired, Message: "must not be empty"}
+	}
+
+	invalid := &Error{Rule: RuleEmail, Message: "must be a plain address like name@example.com"}
+	if len(address) > maxEmailLength {
+		return "", invalid
+	}
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Name != "" || parsed.Address != address {
+		return "", invalid
+	}
+
+	local, domain, _ := strings.Cut(address, "@")
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", invalid
+	}
+
+	return local + "@" + strings.ToLower(domain), nil
+}
+
+// Contact trims the fields of a contact, checks that it has a first and last name and normalizes
+// its phone and email when set. It returns every invalid field.
+func Contact(contact models.Contact) (models.Contact, []models.FieldError) {
+	contact.FirstName = strings.TrimSpace(contact.FirstName)
+	contact.LastName = strings.TrimSpace(contact.LastName)
+	contact.Company = strings.TrimSpace(contact.Company)
+	contact.JobTitle = strings.TrimSpace(contact.JobTitle)
+
+	var errs []models.FieldError
+	if contact.FirstName == "" {
+		errs = append(errs, FieldError("firstName", &Error{Rule: RuleRequired, Message: "is required"}))
+	}
+	if contact.LastName == "" {
+		errs = append(errs, FieldError("lastName", &Error{Rule: RuleRequired, Message: "is required"}))
+	}
+
+	contact.Email = strings.TrimSpace(contact.Email)
+	if contact.Email != "" {
+		email, err := Email(contact.Email)
+		if err != nil {
+			errs = append(errs, FieldError("email", err))
+		}
+		contact.Email = email
+	}
+
+	// The E.164 form is derived from the phone given, whatever the contact had before
+	contact.Phone = strings.TrimSpace(contact.Phone)
+	contact.PhoneE164 = ""
+	if contact.Phone != "" {
+		phone, err := ParsePhone(contact.Phone)
+		if err != nil {
+			errs = append(errs, FieldError("phone", err))
+		}
+		contact.Phone, contact.PhoneE164 = phone.Display, phone.E164
+	}
+
+	return contact, errs
+}
+
+// FieldError reports a validation error of a field, e.g. "phone must have between 8 and 15 digits"
+func FieldError(field string, err error) models.FieldError {
+	rule := field
+	var e *Error
+	if errors.As(err, &e) {
+		rule = e.Rule
+	}
+	return models.FieldError{Field: field, Rule: rule, Message: field + " " + err.Error()}
+}
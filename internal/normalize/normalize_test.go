@@ -0,0 +1,100 @@
+package normalize
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePhone(t *testing.T) {
+	tests := []struct {
+		in   string
+		e164 string
+		rule string // the broken rule, empty if the phone is valid
+	}{
+		{in: "+15551234567", e164: "+15551234567"},
+		{in: "+1 (555) 123-4567", e164: "+15551234567"},
+		{in: "  +1.555.123.4567  ", e164: "+15551234567"},
+		{in: "555-123-4567", e164: "+15551234567"},
+		{in: "15551234567", e164: "+15551234567"},
+		{in: "+44 20 7946 0958", e164: "+442079460958"},
+		{in: "0044 20 7946 0958", e164: "+442079460958"},
+		{in: "+49 30 1234567", e164: "+49301234567"},
+		{in: "", rule: RuleRequired},
+		{in: "   ", rule: RuleRequired},
+		{in: "+1 555 CALL NOW", rule: RulePhone},
+		{in: "555+1234567", rule: RulePhone},
+		{in: "ext. 12", rule: RulePhone},
+		{in: "020 7946 0958", rule: RulePhoneCountryCode},
+		{in: "+0 555 123 4567", rule: RulePhoneCountryCode},
+		{in: "000 555 123 4567", rule: RulePhoneCountryCode},
+		{in: "+44 1234", rule: RulePhoneLength},
+		{in: "+44 1234 5678 9012 3456", rule: RulePhoneLength},
+		{in: "+1 555 123 456", rule: RulePhoneLength},
+		{in: "+1 555 123 45678", rule: RulePhoneLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			phone, err := ParsePhone(tt.in)
+			if tt.rule != "" {
+				var e *Error
+				if !errors.As(err, &e) || e.Rule != tt.rule {
+					t.Fatalf("ParsePhone(%q) error = %v, want rule %q", tt.in, err, tt.rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePhone(%q) error = %v", tt.in, err)
+			}
+			if phone.E164 != tt.e164 {
+				t.Errorf("ParsePhone(%q).E164 = %q, want %q", tt.in, phone.E164, tt.e164)
+			}
+			if want := strings.TrimSpace(tt.in); phone.Display != want {
+				t.Errorf("ParsePhone(%q).Display = %q, want %q", tt.in, phone.Display, want)
+			}
+		})
+	}
+}
+
+func TestEmail(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		rule string // the broken rule, empty if the email is valid
+	}{
+		{in: "jane@example.com", want: "jane@example.com"},
+		{in: "  jane@example.com ", want: "jane@example.com"},
+		{in: "Jane.Doe@Example.COM", want: "Jane.Doe@example.com"},
+		{in: "jane+tag@mail.example.co.uk", want: "jane+tag@mail.example.co.uk"},
+		{in: "", rule: RuleRequired},
+		{in: "jane", rule: RuleEmail},
+		{in: "jane@", rule: RuleEmail},
+		{in: "@example.com", rule: RuleEmail},
+		{in: "jane@localhost", rule: RuleEmail},
+		{in: "jane@example.", rule: RuleEmail},
+		{in: "jane@.example.com", rule: RuleEmail},
+		{in: "Jane <jane@example.com>", rule: RuleEmail},
+		{in: "jane@example.com, john@example.com", rule: RuleEmail},
+		{in: "jane doe@example.com", rule: RuleEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Email(tt.in)
+			if tt.rule != "" {
+				var e *Error
+				if !errors.As(err, &e) || e.Rule != tt.rule {
+					t.Fatalf("Email(%q) error = %v, want rule %q", tt.in, err, tt.rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Email(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Email(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
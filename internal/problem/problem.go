@@ -0,0 +1,119 @@
+package problem
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/surfe/mock-api/internal/models"
+)
+
+// ContentType is the media type of problem details responses
+const ContentType = "application/problem+json"
+
+// TraceHeader carries the trace ID of every response
+const TraceHeader = "X-Trace-Id"
+
+// typePrefix is prepended to the problem types below
+const typePrefix = "/problems/"
+
+// Problem types for causes clients may want to tell apart. Errors without one of these get a type
+// derived from their status, such as "/problems/bad-request" or "/problems/not-found".
+const (
+	TypeValidation           = typePrefix + "validation-error"
+	TypeInvalidBody          = typePrefix + "invalid-request-body"
+	TypeContactNotFound      = typePrefix + "contact-not-found"
+	TypeEnrichmentNotFound   = typePrefix + "enrichment-not-found"
+	TypeBatchNotFound        = typePrefix + "batch-not-found"
+	TypeSnapshotNotFound     = typePrefix + "snapshot-not-found"
+	TypeThirdPartyNotFound   = typePrefix + "third-party-not-found"
+	TypeStaticEnrichment     = typePrefix + "static-enrichment"
+	TypeEnrichmentFinished   = typePrefix + "enrichment-finished"
+	TypeEnrichmentRunning    = typePrefix + "enrichment-running"
+	TypeNothingToRetry       = typePrefix + "nothing-to-retry"
+	TypeIdempotencyKeyReused = typePrefix + "idempotency-key-reused"
+	TypeNoVirtualClock       = typePrefix + "no-virtual-clock"
+	TypeNoPendingTimer       = typePrefix + "no-pending-timer"
+	TypeContactModified      = typePrefix + "contact-modified"
+	TypeInjectedFault        = typePrefix + "injected-fault"
+)
+
+// contextKey keys the request state set by Middleware
+type contextKey struct{}
+
+// requestState is what Middleware records about a request
+type requestState struct {
+	traceID string
+	legacy  bool
+}
+
+// Middleware gives every request a trace ID, taken from its W3C traceparent header when it has one,
+// and sends it back in the X-Trace-Id header. With legacy set, errors are written as
+// models.ErrorResponse instead of problem details.
+func Middleware(legacy bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &requestState{traceID: traceID(r), legacy: legacy}
+		w.Header().Set(TraceHeader, state.traceID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, state)))
+	})
+}
+
+// TraceID returns the trace ID Middleware gave a request, or an empty string outside of it
+func TraceID(r *http.Request) string {
+	if state, ok := r.Context().Value(contextKey{}).(*requestState); ok {
+		return state.traceID
+	}
+	return ""
+}
+
+// Write writes an error response for a request. An empty problem type is derived from the status.
+func Write(w http.ResponseWriter, r *http.Request, status int, problemType, detail string, errs []models.FieldError) {
+	state, _ := r.Context().Value(contextKey{}).(*requestState)
+	if state != nil && state.legacy {
+		writeJSON(w, "application/json", status, models.ErrorResponse{
+			Error:   http.StatusText(status),
+			Code:    status,
+			Message: detail,
+			Errors:  errs,
+		})
+		return
+	}
+
+	if problemType == "" {
+		problemType = typePrefix + strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "-")
+	}
+	writeJSON(w, ContentType, status, models.Problem{
+		Type:     problemType,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		TraceID:  TraceID(r),
+		Errors:   errs,
+	})
+}
+
+// writeJSON writes a JSON body with the given content type
+func writeJSON(w http.ResponseWriter, contentType string, status int, body any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// traceID returns the trace ID of a valid traceparent header, or a new random one
+func traceID(r *http.Request) string {
+	// traceparent is version-traceid-parentid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	parts := strings.Split(r.Header.Get("traceparent"), "-")
+	if len(parts) == 4 && len(parts[1]) == 32 && parts[1] != strings.Repeat("0", 32) {
+		if _, err := hex.DecodeString(parts[1]); err == nil {
+			return strings.ToLower(parts[1])
+		}
+	}
+
+	id := uuid.New()
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/surfe/mock-api/internal/data"
+	"github.com/surfe/mock-api/internal/models"
+)
+
+// LoadConfigs reads provider configurations from a JSON file holding an array of providers.
+// Fields left out of an entry fall back to data.DefaultProviderConfig.
+func LoadConfigs(path string) ([]models.ProviderConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider config: %w", err)
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse provider config: %w", err)
+	}
+	return ParseConfigs(entries)
+}
+
+// ParseConfigs decodes and validates provider configurations, one JSON object per provider.
+// Fields left out of an entry fall back to data.DefaultProviderConfig.
+func ParseConfigs(entries []json.RawMessage) ([]models.ProviderConfig, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("provider config must contain at least one provider")
+	}
+
+	configs := make([]models.ProviderConfig, 0, len(entries))
+	seen := make(map[string]bool)
+	for i, entry := range entries {
+		// Decode on top of the defaults so only the overridden fields need to be written;
+		// successRate entries are merged into the default rates
+		config := data.DefaultProviderConfig(models.Provider{})
+		if err := json.Unmarshal(entry, &config); err != nil {
+			return nil, fmt.Errorf("provider %d: %w", i, err)
+		}
+
+		// A latency given in the file replaces the default one as a whole,
+		// a normal distribution must not inherit the default uniform bounds
+		var override struct {
+			Latency *models.LatencyDistribution `json:"latency"`
+		}
+		if err := json.Unmarshal(entry, &override); err == nil && override.Latency != nil {
+			config.Latency = *override.Latency
+		}
+
+		// Rates of job types the provider does not support are meaningless
+		for job := range config.SuccessRate {
+			if !config.Supports(job) {
+				delete(config.SuccessRate, job)
+			}
+		}
+
+		if err := ValidateConfig(config); err != nil {
+			return nil, fmt.Errorf("provider %d: %w", i, err)
+		}
+		if seen[config.ID] {
+			return nil, fmt.Errorf("provider %d: duplicate id %s", i, config.ID)
+		}
+		seen[config.ID] = true
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// ValidateConfig checks that a provider configuration is usable
+func ValidateConfig(config models.ProviderConfig) error {
+	if config.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if config.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if len(config.Jobs) == 0 {
+		return fmt.Errorf("jobs must contain 'phone' and/or 'email'")
+	}
+	for _, job := range config.Jobs {
+		if job != models.JobTypePhone && job != models.JobTypeEmail {
+			return fmt.Errorf("jobs must contain 'phone' and/or 'email', got %q", job)
+		}
+	}
+
+	if config.CreditCost < 0 {
+		return fmt.Errorf("creditCost must not be negative")
+	}
+
+	for job, rate := range config.SuccessRate {
+		if job != models.JobTypePhone && job != models.JobTypeEmail {
+			return fmt.Errorf("successRate has unknown job type %q", job)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("successRate for %s must be between 0 and 1", job)
+		}
+	}
+	if config.MatchedSuccessRate < 0 || config.MatchedSuccessRate > 1 {
+		return fmt.Errorf("matchedSuccessRate must be between 0 and 1")
+	}
+
+	latency := config.Latency
+	switch latency.Distribution {
+	case "uniform":
+		if latency.MinMs < 0 || latency.MaxMs < latency.MinMs {
+			return fmt.Errorf("latency must have 0 <= minMs <= maxMs")
+		}
+	case "normal":
+		if latency.MeanMs < 0 || latency.StdDevMs < 0 {
+			return fmt.Errorf("latency meanMs and stdDevMs must not be negative")
+		}
+		if latency.MaxMs > 0 && latency.MaxMs < latency.MinMs {
+			return fmt.Errorf("latency maxMs must not be lower than minMs")
+		}
+	default:
+		return fmt.Errorf("latency distribution must be 'uniform' or 'normal'")
+	}
+
+	return nil
+}
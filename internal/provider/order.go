@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/surfe/mock-api/internal/models"
+)
+
+// ResolveOrder returns the IDs of the providers a job walks through, in order.
+// An explicit provider list is validated against the configured providers; otherwise
+// every provider supporting the job is ordered by the strategy, ties broken by name.
+func ResolveOrder(configs []models.ProviderConfig, job models.JobType, order models.ProviderOrder) ([]string, error) {
+	if len(order.Providers) > 0 {
+		byID := make(map[string]models.ProviderConfig, len(configs))
+		for _, config := range configs {
+			byID[config.ID] = config
+		}
+
+		seen := make(map[string]bool, len(order.Providers))
+		for _, id := range order.Providers {
+			config, exists := byID[id]
+			if !exists {
+				return nil, fmt.Errorf("unknown provider %s", id)
+			}
+			if !config.Supports(job) {
+				return nil, fmt.Errorf("provider %s does not support %s", id, job)
+			}
+			if seen[id] {
+				return nil, fmt.Errorf("provider %s is listed twice", id)
+			}
+			seen[id] = true
+		}
+		return append([]string(nil), order.Providers...), nil
+	}
+
+	var candidates []models.ProviderConfig
+	for _, config := range configs {
+		if config.Supports(job) {
+			candidates = append(candidates, config)
+		}
+	}
+
+	var less func(a, b models.ProviderConfig) bool
+	switch order.Strategy {
+	case "", models.ProviderStrategyDefault:
+		less = func(a, b models.ProviderConfig) bool { return false }
+	case models.ProviderStrategyCheapestFirst:
+		less = func(a, b models.ProviderConfig) bool { return a.CreditCost < b.CreditCost }
+	case models.ProviderStrategyHighestHitRateFirst:
+		less = func(a, b models.ProviderConfig) bool { return a.SuccessRate[job] > b.SuccessRate[job] }
+	default:
+		return nil, fmt.Errorf("strategy must be '%s', '%s' or '%s'",
+			models.ProviderStrategyDefault, models.ProviderStrategyCheapestFirst, models.ProviderStrategyHighestHitRateFirst)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if less(candidates[i], candidates[j]) {
+			return true
+		}
+		if less(candidates[j], candidates[i]) {
+			return false
+		}
+		if candidates[i].Name != candidates[j].Name {
+			return candidates[i].Name < candidates[j].Name
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	ids := make([]string, len(candidates))
+	for i, config := range candidates {
+		ids[i] = config.ID
+	}
+	return ids, nil
+}
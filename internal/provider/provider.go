@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/surfe/mock-api/internal/clock"
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/rng"
+)
+
+// Request describes a single lookup sent to a provider
+type Request struct {
+	EnrichmentID string
+	Contact      models.Contact
+	Job          models.JobType
+
+	// ContactInfoMatches is true when the contact info sent with the enrichment matches
+	// the third-party data, which makes providers more likely to find the value
+	ContactInfoMatches bool
+
+	// Rand drives every random decision of the lookup, so a seeded enrichment is reproducible
+	Rand *rng.Source
+
+	// Clock measures the lookup delays, so they can be fast-forwarded
+	Clock clock.Clock
+}
+
+// Result is a provider's answer to a lookup
+type Result struct {
+	Found bool
+	Value string
+}
+
+// Provider looks up a contact's phone number or email.
+// Lookup must stop and return ctx.Err() as soon as the context is cancelled.
+type Provider interface {
+	// Config returns the provider and its configured behaviour
+	Config() models.ProviderConfig
+
+	// Lookup searches for the requested job's value
+	Lookup(ctx context.Context, req Request) (Result, error)
+}
+
+// sleep waits for the given duration on the clock or until the context is cancelled.
+// Without a clock it waits on the wall clock.
+func sleep(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	if clk == nil {
+		clk = clock.Real{}
+	}
+
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
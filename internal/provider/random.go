@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/surfe/mock-api/internal/data"
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/rng"
+)
+
+// Random is a provider that takes a random time and finds the contact's mock
+// enrichment data with the probability set in its configuration
+type Random struct {
+	config   models.ProviderConfig
+	mockData *data.MockData
+}
+
+// NewRandom creates a random provider backed by the mock enrichment data
+func NewRandom(config models.ProviderConfig, mockData *data.MockData) *Random {
+	return &Random{config: config, mockData: mockData}
+}
+
+// NewRandomProviders creates a random provider for every provider configured in the mock data
+func NewRandomProviders(mockData *data.MockData) []Provider {
+	configs := mockData.GetAllProviderConfigs()
+	providers := make([]Provider, 0, len(configs))
+	for _, config := range configs {
+		providers = append(providers, NewRandom(config, mockData))
+	}
+	return providers
+}
+
+// Config returns the provider and its configured behaviour
+func (p *Random) Config() models.ProviderConfig {
+	return p.config
+}
+
+// Lookup waits for a delay drawn from the latency distribution, then finds the value
+// with the configured success rate
+func (p *Random) Lookup(ctx context.Context, req Request) (Result, error) {
+	rand := req.Rand
+	if rand == nil {
+		rand = rng.New(time.Now().UnixNano())
+	}
+
+	if err := sleep(ctx, req.Clock, p.delay(rand)); err != nil {
+		return Result{}, err
+	}
+
+	successRate := p.config.SuccessRate[req.Job]
+	if req.ContactInfoMatches && p.config.MatchedSuccessRate > successRate {
+		successRate = p.config.MatchedSuccessRate
+	}
+	if rand.Float32() >= successRate {
+		return Result{}, nil
+	}
+
+	// A hit only counts if there is mock data to return
+	phone, email, exists := p.mockData.GetEnrichmentData(req.Contact.ID)
+	if !exists {
+		return Result{}, nil
+	}
+
+	value := phone
+	if req.Job == models.JobTypeEmail {
+		value = email
+	}
+	return Result{Found: value != "", Value: value}, nil
+}
+
+// delay draws a lookup duration from the latency distribution
+func (p *Random) delay(rand *rng.Source) time.Duration {
+	latency := p.config.Latency
+
+	var ms int
+	switch latency.Distribution {
+	case "normal":
+		ms = latency.MeanMs + int(rand.NormFloat64()*float64(latency.StdDevMs))
+		if ms < latency.MinMs {
+			ms = latency.MinMs
+		}
+		if latency.MaxMs > 0 && ms > latency.MaxMs {
+			ms = latency.MaxMs
+		}
+	default:
+		ms = latency.MinMs
+		if spread := latency.MaxMs - latency.MinMs; spread > 0 {
+			ms += rand.Intn(spread + 1)
+		}
+	}
+
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/surfe/mock-api/internal/models"
+)
+
+// Step is one scripted answer of a provider
+type Step struct {
+	Delay time.Duration
+	Found bool
+	Value string
+	Err   error
+}
+
+// Scripted is a provider that replays predefined answers, for tests and reproducible demos.
+// Each job type has its own queue of steps; once a queue is empty every lookup misses immediately.
+type Scripted struct {
+	config models.ProviderConfig
+
+	mu    sync.Mutex
+	steps map[models.JobType][]Step
+	calls []Request
+}
+
+// NewScripted creates a scripted provider with no answers queued
+func NewScripted(config models.ProviderConfig) *Scripted {
+	return &Scripted{
+		config: config,
+		steps:  make(map[models.JobType][]Step),
+	}
+}
+
+// On queues answers for a job type and returns the provider for chaining
+func (p *Scripted) On(job models.JobType, steps ...Step) *Scripted {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps[job] = append(p.steps[job], steps...)
+	return p
+}
+
+// Calls returns every lookup the provider received, in order
+func (p *Scripted) Calls() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Request(nil), p.calls...)
+}
+
+// Config returns the provider and its configured behaviour
+func (p *Scripted) Config() models.ProviderConfig {
+	return p.config
+}
+
+// Lookup answers with the next queued step for the requested job
+func (p *Scripted) Lookup(ctx context.Context, req Request) (Result, error) {
+	p.mu.Lock()
+	p.calls = append(p.calls, req)
+	var step Step
+	if queue := p.steps[req.Job]; len(queue) > 0 {
+		step = queue[0]
+		p.steps[req.Job] = queue[1:]
+	}
+	p.mu.Unlock()
+
+	if err := sleep(ctx, req.Clock, step.Delay); err != nil {
+		return Result{}, err
+	}
+	if step.Err != nil {
+		return Result{}, step.Err
+	}
+	return Result{Found: step.Found && step.Value != "", Value: step.Value}, nil
+}
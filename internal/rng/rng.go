@@ -0,0 +1,64 @@
+package rng
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync"
+)
+
+// MaxSeed bounds the seeds drawn by Source.Seed so they survive a round trip through
+// JSON numbers, which JavaScript clients read as float64
+const MaxSeed = 1 << 53
+
+// Source is a random number generator that is safe for concurrent use.
+// The same seed always yields the same sequence of numbers.
+type Source struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// New creates a source seeded with the given value
+func New(seed int64) *Source {
+	return &Source{r: rand.New(rand.NewSource(seed))}
+}
+
+// Derive creates an independent source for a named stream of a seed, e.g. one job of an enrichment,
+// so streams consumed in parallel do not depend on each other's timing
+func Derive(seed int64, stream string) *Source {
+	h := fnv.New64a()
+	h.Write([]byte(stream))
+	return New(seed ^ int64(h.Sum64()))
+}
+
+// Intn returns a number in [0, n)
+func (s *Source) Intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Intn(n)
+}
+
+// Int63n returns a number in [0, n)
+func (s *Source) Int63n(n int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Int63n(n)
+}
+
+// Float32 returns a number in [0.0, 1.0)
+func (s *Source) Float32() float32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Float32()
+}
+
+// NormFloat64 returns a normally distributed number with mean 0 and standard deviation 1
+func (s *Source) NormFloat64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.NormFloat64()
+}
+
+// Seed draws a seed for another source
+func (s *Source) Seed() int64 {
+	return s.Int63n(MaxSeed)
+}
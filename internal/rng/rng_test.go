@@ -0,0 +1,68 @@
+package rng
+
+import (
+	"fmt"
+	"testing"
+)
+
+// draws returns the first n numbers of a source
+func draws(s *Source, n int) []int64 {
+	values := make([]int64, n)
+	for i := range values {
+		values[i] = s.Int63n(MaxSeed)
+	}
+	return values
+}
+
+func TestDerive(t *testing.T) {
+	tests := []struct {
+		seed   int64
+		stream string
+	}{
+		{seed: 0, stream: ""},
+		{seed: 0, stream: "phone"},
+		{seed: 42, stream: ""},
+		{seed: 42, stream: "phone"},
+		{seed: 42, stream: "email"},
+		{seed: 42, stream: "retry"},
+		{seed: 42, stream: "0"},
+		{seed: 42, stream: "1"},
+		{seed: 43, stream: "phone"},
+		{seed: -42, stream: "phone"},
+		{seed: MaxSeed - 1, stream: "handlers"},
+		{seed: MaxSeed - 1, stream: "worker"},
+	}
+
+	seen := make(map[string]string)
+	for _, tt := range tests {
+		name := fmt.Sprintf("%d/%q", tt.seed, tt.stream)
+		got := draws(Derive(tt.seed, tt.stream), 5)
+
+		// The same seed and stream always yield the same sequence
+		if again := draws(Derive(tt.seed, tt.stream), 5); fmt.Sprint(again) != fmt.Sprint(got) {
+			t.Errorf("Derive(%s) is not reproducible: %v, then %v", name, got, again)
+		}
+
+		// Every other seed or stream yields a different one
+		key := fmt.Sprint(got)
+		if other, ok := seen[key]; ok {
+			t.Errorf("Derive(%s) yields the same sequence as Derive(%s)", name, other)
+		}
+		seen[key] = name
+	}
+}
+
+func TestSeed(t *testing.T) {
+	for _, seed := range []int64{0, 1, 42, -1, MaxSeed} {
+		s := New(seed)
+		for i := 0; i < 100; i++ {
+			if got := s.Seed(); got < 0 || got >= MaxSeed {
+				t.Fatalf("New(%d).Seed() = %d, want a value in [0, %d)", seed, got, int64(MaxSeed))
+			}
+		}
+
+		if a, b := New(seed).Seed(), New(seed).Seed(); a != b {
+			t.Errorf("New(%d).Seed() is not reproducible: %d, then %d", seed, a, b)
+		}
+	}
+}
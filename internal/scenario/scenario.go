@@ -0,0 +1,123 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/normalize"
+)
+
+// LoadDir reads every *.json file of a directory as one scenario.
+// A scenario without a name is named after its file.
+func LoadDir(dir string) ([]models.Scenario, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scenarios: %w", err)
+	}
+	sort.Strings(paths)
+
+	scenarios := make([]models.Scenario, 0, len(paths))
+	seen := make(map[string]string)
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenario: %w", err)
+		}
+
+		var scenario models.Scenario
+		if err := json.Unmarshal(raw, &scenario); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse scenario: %w", filepath.Base(path), err)
+		}
+		if scenario.Name == "" {
+			scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		if err := Validate(scenario); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if other, exists := seen[scenario.Name]; exists {
+			return nil, fmt.Errorf("%s: scenario %s is already defined in %s", filepath.Base(path), scenario.Name, other)
+		}
+		seen[scenario.Name] = filepath.Base(path)
+
+		scenarios = append(scenarios, scenario)
+	}
+
+	return scenarios, nil
+}
+
+// Validate checks that a scenario can be replayed
+func Validate(scenario models.Scenario) error {
+	if scenario.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if len(scenario.Jobs) == 0 {
+		return fmt.Errorf("jobs must contain 'phone' and/or 'email'")
+	}
+	for job, steps := range scenario.Jobs {
+		if job != models.JobTypePhone && job != models.JobTypeEmail {
+			return fmt.Errorf("jobs has unknown job type %q", job)
+		}
+		for i, step := range steps {
+			if err := validateStep(job, step); err != nil {
+				return fmt.Errorf("jobs.%s[%d]: %w", job, i, err)
+			}
+		}
+	}
+
+	switch scenario.Status {
+	case "", models.EnrichmentStatusCompleted, models.EnrichmentStatusFailed:
+	default:
+		return fmt.Errorf("status must be 'completed' or 'failed'")
+	}
+
+	return nil
+}
+
+// validateStep checks a single provider answer of a job of a scenario
+func validateStep(job models.JobType, step models.ScenarioStep) error {
+	switch step.Outcome {
+	case models.AttemptOutcomeFound, models.AttemptOutcomeNotFound, models.AttemptOutcomeError:
+	default:
+		return fmt.Errorf("outcome must be '%s', '%s' or '%s'",
+			models.AttemptOutcomeFound, models.AttemptOutcomeNotFound, models.AttemptOutcomeError)
+	}
+	if step.DelayMs < 0 {
+		return fmt.Errorf("delayMs must not be negative")
+	}
+	if step.Value != "" && step.Outcome != models.AttemptOutcomeFound {
+		return fmt.Errorf("value is only allowed when the outcome is '%s'", models.AttemptOutcomeFound)
+	}
+	if step.Error != "" && step.Outcome != models.AttemptOutcomeError {
+		return fmt.Errorf("error is only allowed when the outcome is '%s'", models.AttemptOutcomeError)
+	}
+	if step.Value != "" {
+		// The value is stored like a contact field, so it must pass the same checks
+		var err error
+		if job == models.JobTypePhone {
+			_, err = normalize.ParsePhone(step.Value)
+		} else {
+			_, err = normalize.Email(step.Value)
+		}
+		if err != nil {
+			return fmt.Errorf("value %w", err)
+		}
+	}
+	return nil
+}
+
+// Finds reports whether one of the steps finds the job's value
+func Finds(steps []models.ScenarioStep) bool {
+	for _, step := range steps {
+		if step.Outcome == models.AttemptOutcomeFound {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/surfe/mock-api/internal/clock"
+	"github.com/surfe/mock-api/internal/data"
+	"github.com/surfe/mock-api/internal/database"
+	"github.com/surfe/mock-api/internal/models"
+)
+
+const (
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>"
+	SignatureHeader = "X-Mock-Signature"
+	// TimestampHeader carries the Unix time the request was signed at
+	TimestampHeader = "X-Mock-Timestamp"
+	// EventHeader carries the event name, e.g. "enrichment.completed"
+	EventHeader = "X-Mock-Event"
+	// AttemptHeader carries the 1-based delivery attempt number
+	AttemptHeader = "X-Mock-Attempt"
+)
+
+// Config holds webhook delivery configuration
+type Config struct {
+	// Secret is the HMAC key used to sign every request
+	Secret string
+
+	// MaxAttempts is how many times a delivery is tried before giving up
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry, doubled after every failed attempt
+	InitialBackoff time.Duration
+
+	// Timeout is how long a single delivery attempt may take
+	Timeout time.Duration
+
+	// Clock times the backoff between attempts, so retries follow the virtual clock
+	Clock clock.Clock
+}
+
+// DefaultConfig returns the default webhook configuration
+func DefaultConfig() Config {
+	return Config{
+		Secret:         "mock-webhook-secret",
+		MaxAttempts:    5,
+		InitialBackoff: 1 * time.Second, // 1s, 2s, 4s, 8s between attempts
+		Timeout:        10 * time.Second,
+		Clock:          clock.Real{},
+	}
+}
+
+// Dispatcher POSTs finished enrichments to their callback URLs
+type Dispatcher struct {
+	db       *database.DB
+	mockData *data.MockData
+	config   Config
+	client   *http.Client
+
+	// ctx is cancelled by CancelPending to stop the deliveries started before it
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a new webhook dispatcher
+func New(db *database.DB, mockData *data.MockData, config Config) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		db:       db,
+		mockData: mockData,
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// CancelPending stops every delivery in flight or waiting to be retried, such as when the state is reset.
+// Deliveries dispatched afterwards are not affected.
+func (d *Dispatcher) CancelPending() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.cancel()
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+}
+
+// Dispatch delivers the final state of an enrichment to its callback URL in the background.
+// Does nothing if the enrichment was started without a callback URL.
+func (d *Dispatcher) Dispatch(enrichmentID string) {
+	enrichment, err := d.db.GetEnrichmentDetails(enrichmentID, d.mockData.GetProvider)
+	if err != nil {
+		log.Printf("Error loading enrichment %s for webhook: %v", enrichmentID, err)
+		return
+	}
+	if enrichment == nil || enrichment.CallbackURL == "" {
+		return
+	}
+
+	event := "enrichment." + string(enrichment.Status)
+
+	body, err := json.Marshal(enrichment)
+	if err != nil {
+		log.Printf("Error marshalling webhook payload for enrichment %s: %v", enrichmentID, err)
+		return
+	}
+
+	d.mu.Lock()
+	ctx := d.ctx
+	d.mu.Unlock()
+
+	go d.deliver(ctx, enrichment.ID, enrichment.CallbackURL, event, body)
+}
+
+// deliver POSTs the payload until it is accepted, the attempts run out or ctx is cancelled, recording every attempt
+func (d *Dispatcher) deliver(ctx context.Context, enrichmentID, url, event string, body []byte) {
+	backoff := d.config.InitialBackoff
+
+	for attempt := 1; attempt <= d.config.MaxAttempts; attempt++ {
+		delivery := d.attempt(ctx, enrichmentID, url, event, body, attempt)
+		// A cancelled delivery belongs to the state that was replaced, so it is not recorded in the new one
+		if ctx.Err() != nil {
+			log.Printf("Cancelled %s webhook for enrichment %s", event, enrichmentID)
+			return
+		}
+		if err := d.db.CreateWebhookDelivery(delivery); err != nil {
+			log.Printf("Error recording webhook delivery for enrichment %s: %v", enrichmentID, err)
+		}
+
+		if delivery.Success {
+			log.Printf("Delivered %s webhook for enrichment %s to %s (attempt %d)", event, enrichmentID, url, attempt)
+			return
+		}
+
+		if attempt < d.config.MaxAttempts {
+			log.Printf("Webhook delivery for enrichment %s failed (attempt %d), retrying in %v", enrichmentID, attempt, backoff)
+			timer := d.config.Clock.NewTimer(backoff)
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				timer.Stop()
+				log.Printf("Cancelled %s webhook for enrichment %s", event, enrichmentID)
+				return
+			}
+			backoff *= 2
+		}
+	}
+
+	log.Printf("Giving up on %s webhook for enrichment %s after %d attempts", event, enrichmentID, d.config.MaxAttempts)
+}
+
+// attempt makes a single signed delivery attempt
+func (d *Dispatcher) attempt(ctx context.Context, enrichmentID, url, event string, body []byte, attempt int) *models.WebhookDelivery {
+	delivery := &models.WebhookDelivery{
+		EnrichmentID: enrichmentID,
+		Event:        event,
+		URL:          url,
+		Attempt:      attempt,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(AttemptHeader, strconv.Itoa(attempt))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(d.config.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+
+	return delivery
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" with the given secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,15 +1,21 @@
 package worker
 
 import (
+	"context"
+	"errors"
 	"log"
-	"math/rand"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/surfe/mock-api/internal/clock"
 	"github.com/surfe/mock-api/internal/data"
 	"github.com/surfe/mock-api/internal/database"
+	"github.com/surfe/mock-api/internal/events"
 	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/provider"
+	"github.com/surfe/mock-api/internal/rng"
+	"github.com/surfe/mock-api/internal/webhook"
 )
 
 // Config holds worker configuration
@@ -20,8 +26,11 @@ type Config struct {
 	// PendingToInProgressDelay is how long an enrichment stays pending before moving to in_progress
 	PendingToInProgressDelay time.Duration
 
-	// ProviderSuccessRate is the probability (0.0 to 1.0) that a provider will find the requested value
-	ProviderSuccessRate float32
+	// Rand draws the seeds of enrichments stored without one
+	Rand *rng.Source
+
+	// Clock paces the polling and the provider lookups
+	Clock clock.Clock
 }
 
 // DefaultConfig returns the default worker configuration
@@ -29,25 +38,50 @@ func DefaultConfig() Config {
 	return Config{
 		PollInterval:             10 * time.Second,
 		PendingToInProgressDelay: 10 * time.Second, // Move to in_progress after 10s
-		ProviderSuccessRate:      0.2,              // 20% chance of finding the value
+		Rand:                     rng.New(time.Now().UnixNano()),
+		Clock:                    clock.Real{},
 	}
 }
 
 // Worker processes enrichments in the background
 type Worker struct {
-	db       *database.DB
-	mockData *data.MockData
-	config   Config
-	stopCh   chan struct{}
+	db        *database.DB
+	mockData  *data.MockData
+	events    *events.Broker
+	webhooks  *webhook.Dispatcher
+	providers []provider.Provider
+	config    Config
+	stopCh    chan struct{}
+
+	// running holds the cancel function of every enrichment being processed
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+
+	// pollMu is held while enrichments are picked up, so Pause cannot miss one being started
+	pollMu sync.Mutex
+	paused bool
+	// ctx is the parent of every enrichment's context, cancelled by Pause
+	ctx       context.Context
+	cancelAll context.CancelFunc
+	// processing counts the enrichments being processed so Pause can wait for them
+	processing sync.WaitGroup
 }
 
-// New creates a new background worker
-func New(db *database.DB, mockData *data.MockData, config Config) *Worker {
+// New creates a new background worker that walks enrichments through the given providers,
+// publishes their progress to the broker and delivers finished enrichments through the webhook dispatcher
+func New(db *database.DB, mockData *data.MockData, broker *events.Broker, webhooks *webhook.Dispatcher, providers []provider.Provider, config Config) *Worker {
+	ctx, cancelAll := context.WithCancel(context.Background())
 	return &Worker{
-		db:       db,
-		mockData: mockData,
-		config:   config,
-		stopCh:   make(chan struct{}),
+		db:        db,
+		mockData:  mockData,
+		events:    broker,
+		webhooks:  webhooks,
+		providers: providers,
+		config:    config,
+		stopCh:    make(chan struct{}),
+		running:   make(map[string]context.CancelFunc),
+		ctx:       ctx,
+		cancelAll: cancelAll,
 	}
 }
 
@@ -66,17 +100,58 @@ func (w *Worker) Stop() {
 	close(w.stopCh)
 }
 
+// Pause stops picking up enrichments, interrupts every provider lookup in flight and waits
+// for the enrichments being processed to let go, so the state can be replaced safely.
+// Interrupted enrichments are left in_progress.
+func (w *Worker) Pause() {
+	w.pollMu.Lock()
+	w.paused = true
+	w.cancelAll()
+	w.pollMu.Unlock()
+
+	w.processing.Wait()
+}
+
+// Resume undoes Pause and restarts the processing of every in_progress enrichment,
+// such as those interrupted by Pause or restored from a snapshot
+func (w *Worker) Resume() {
+	w.pollMu.Lock()
+	defer w.pollMu.Unlock()
+	w.ctx, w.cancelAll = context.WithCancel(context.Background())
+	w.paused = false
+
+	enrichments, err := w.db.GetInProgressEnrichments(0)
+	if err != nil {
+		log.Printf("Error fetching in_progress enrichments: %v", err)
+		return
+	}
+	for _, e := range enrichments {
+		log.Printf("Resuming enrichment %s", e.ID)
+		w.startProcessing(e.ID, e.UserID)
+	}
+}
+
 func (w *Worker) run() {
-	ticker := time.NewTicker(w.config.PollInterval)
-	defer ticker.Stop()
+	// Poll on the clock so fast-forwarding it also moves pending enrichments along
+	poll := w.config.Clock.NewTimer(w.config.PollInterval)
+	defer func() { poll.Stop() }()
+
+	// Watch for cancellations and failures, such as those of a deleted contact, so running provider lookups can be interrupted
+	sub := w.events.Subscribe()
+	defer sub.Close()
 
 	// Run immediately on start
 	w.processEnrichments()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-poll.C():
 			w.processEnrichments()
+			poll = w.config.Clock.NewTimer(w.config.PollInterval)
+		case e := <-sub.C:
+			if e.Type == events.TypeStatusChanged && (e.Status == models.EnrichmentStatusCancelled || e.Status == models.EnrichmentStatusFailed) {
+				w.cancelRunning(e.EnrichmentID)
+			}
 		case <-w.stopCh:
 			log.Println("Stopping enrichment worker")
 			return
@@ -90,6 +165,12 @@ func (w *Worker) processEnrichments() {
 }
 
 func (w *Worker) processPendingEnrichments() {
+	w.pollMu.Lock()
+	defer w.pollMu.Unlock()
+	if w.paused {
+		return
+	}
+
 	enrichments, err := w.db.GetPendingEnrichments(w.config.PendingToInProgressDelay)
 	if err != nil {
 		log.Printf("Error fetching pending enrichments: %v", err)
@@ -104,21 +185,37 @@ func (w *Worker) processPendingEnrichments() {
 			log.Printf("Error updating enrichment %s to in_progress: %v", e.ID, err)
 			continue
 		}
+		w.publishStatus(e.ID, e.UserID, models.EnrichmentStatusInProgress)
 
 		// Start processing this enrichment through providers in a goroutine
-		go w.processEnrichmentThroughProviders(e.ID, e.UserID)
+		w.startProcessing(e.ID, e.UserID)
 	}
 }
 
+// startProcessing processes an enrichment through the providers in the background, w.pollMu must be held
+func (w *Worker) startProcessing(enrichmentID, userID string) {
+	ctx := w.ctx
+	w.processing.Add(1)
+	go func() {
+		defer w.processing.Done()
+		w.processEnrichmentThroughProviders(ctx, enrichmentID, userID)
+	}()
+}
+
 // processEnrichmentThroughProviders processes an enrichment by checking each provider
 // for the contact's phone number and/or email based on the requested jobs.
 // Runs phone and email jobs in parallel if both are requested.
-func (w *Worker) processEnrichmentThroughProviders(enrichmentID, userID string) {
+func (w *Worker) processEnrichmentThroughProviders(ctx context.Context, enrichmentID, userID string) {
 	// Get the contact
-	contact, exists := w.mockData.GetContact(userID)
-	if !exists {
+	contact, err := w.db.GetContact(userID)
+	if err != nil {
+		log.Printf("Error getting contact for enrichment %s: %v", enrichmentID, err)
+		w.failEnrichment(enrichmentID, userID, "could not read its contact")
+		return
+	}
+	if contact == nil {
 		log.Printf("Contact not found for enrichment %s (userID: %s), marking as failed", enrichmentID, userID)
-		w.db.UpdateEnrichmentStatus(enrichmentID, models.EnrichmentStatusFailed, nil)
+		w.failEnrichment(enrichmentID, userID, "contact "+userID+" not found")
 		return
 	}
 
@@ -126,7 +223,7 @@ func (w *Worker) processEnrichmentThroughProviders(enrichmentID, userID string)
 	jobs, _, err := w.db.GetEnrichmentJobs(enrichmentID)
 	if err != nil {
 		log.Printf("Error getting jobs for enrichment %s: %v", enrichmentID, err)
-		w.db.UpdateEnrichmentStatus(enrichmentID, models.EnrichmentStatusFailed, nil)
+		w.failEnrichment(enrichmentID, userID, "could not read its jobs")
 		return
 	}
 
@@ -142,11 +239,10 @@ func (w *Worker) processEnrichmentThroughProviders(enrichmentID, userID string)
 		}
 	}
 
-	// Get all providers
-	providers := w.mockData.GetAllProviders()
+	providers := w.providers
 	if len(providers) == 0 {
 		log.Printf("No providers available, marking enrichment %s as failed", enrichmentID)
-		w.db.UpdateEnrichmentStatus(enrichmentID, models.EnrichmentStatusFailed, nil)
+		w.failEnrichment(enrichmentID, userID, "no providers configured")
 		return
 	}
 
@@ -156,21 +252,59 @@ func (w *Worker) processEnrichmentThroughProviders(enrichmentID, userID string)
 		log.Printf("Error getting contact info for enrichment %s: %v", enrichmentID, err)
 	}
 
-	// Calculate success rate: 80% if contact info matches, otherwise use base rate
-	successRate := w.config.ProviderSuccessRate
+	// Providers are more likely to find the value when the contact info matches the third-party data
+	contactInfoMatches := false
 	if contactInfo != nil {
 		// Get the full name from contact
 		fullName := contact.FirstName + " " + contact.LastName
 		thirdPartyInfo, exists := w.mockData.GetThirdPartyInfo(fullName)
 		if exists && w.contactInfoMatches(contactInfo, &thirdPartyInfo) {
-			successRate = 0.8 // 80% success rate when contact info matches
-			log.Printf("✅ SUCCESS RATE BOOSTED: Contact info matches third-party data for enrichment %s (user: %s). Success rate increased from %.0f%% to 80%%", enrichmentID, fullName, w.config.ProviderSuccessRate*100)
+			contactInfoMatches = true
+			log.Printf("✅ SUCCESS RATE BOOSTED: Contact info matches third-party data for enrichment %s (user: %s)", enrichmentID, fullName)
 		} else {
-			log.Printf("Contact info provided for enrichment %s (user: %s) but does not match third-party data. Using base success rate of %.0f%%", enrichmentID, fullName, w.config.ProviderSuccessRate*100)
+			log.Printf("Contact info provided for enrichment %s (user: %s) but does not match third-party data. Using base success rate", enrichmentID, fullName)
 		}
 	}
 
-	log.Printf("Processing enrichment %s through %d providers for jobs: %v (success rate: %.0f%%)", enrichmentID, len(providers), jobs, successRate*100)
+	log.Printf("Processing enrichment %s through %d providers for jobs: %v", enrichmentID, len(providers), jobs)
+
+	// Cancelling the enrichment or pausing the worker interrupts the provider lookups in flight
+	ctx, cancel := context.WithCancel(ctx)
+	w.mu.Lock()
+	w.running[enrichmentID] = cancel
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		delete(w.running, enrichmentID)
+		w.mu.Unlock()
+		cancel()
+	}()
+
+	// Walk each job through the providers in the order chosen when the enrichment was started
+	providerOrder, err := w.db.GetEnrichmentProviderOrder(enrichmentID)
+	if err != nil {
+		log.Printf("Error getting provider order for enrichment %s: %v", enrichmentID, err)
+	}
+
+	// Each job draws from its own stream of the enrichment's seed, so the parallel jobs
+	// get the same delays and outcomes whichever one runs first
+	seed := w.enrichmentSeed(enrichmentID)
+
+	// A scenario dictates the providers' answers instead
+	scenario, err := w.db.GetEnrichmentScenario(enrichmentID)
+	if err != nil {
+		log.Printf("Error getting scenario for enrichment %s: %v", enrichmentID, err)
+	}
+	jobProviders := func(jobType string) []provider.Provider {
+		providers := w.orderedProviders(providerOrder[jobType])
+		if scenario != nil {
+			providers = w.scenarioProviders(providers, *contact, models.JobType(jobType), scenario.Jobs[models.JobType(jobType)])
+		}
+		return providers
+	}
+	if scenario != nil {
+		log.Printf("Replaying scenario %s for enrichment %s", scenario.Name, enrichmentID)
+	}
 
 	// Use WaitGroup to wait for all job types to complete
 	var wg sync.WaitGroup
@@ -180,7 +314,7 @@ func (w *Worker) processEnrichmentThroughProviders(enrichmentID, userID string)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			w.processJobForEnrichment(enrichmentID, contact, "phone", providers, successRate)
+			w.processJobForEnrichment(ctx, enrichmentID, *contact, "phone", jobProviders("phone"), contactInfoMatches, rng.Derive(seed, "phone"))
 		}()
 	}
 
@@ -189,13 +323,43 @@ func (w *Worker) processEnrichmentThroughProviders(enrichmentID, userID string)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			w.processJobForEnrichment(enrichmentID, contact, "email", providers, successRate)
+			w.processJobForEnrichment(ctx, enrichmentID, *contact, "email", jobProviders("email"), contactInfoMatches, rng.Derive(seed, "email"))
 		}()
 	}
 
 	// Wait for all jobs to complete
 	wg.Wait()
 
+	// Pausing the worker leaves the enrichment as it is, to be resumed later
+	if ctx.Err() != nil && !w.isStopped(enrichmentID) {
+		log.Printf("Processing of enrichment %s was interrupted", enrichmentID)
+		return
+	}
+
+	// A cancelled or failed enrichment keeps whatever was found so far, nothing else to fill in
+	if w.isStopped(enrichmentID) {
+		log.Printf("Enrichment %s was stopped, skipping final job completion", enrichmentID)
+		return
+	}
+
+	// A failing scenario keeps whatever was found and fails instead of completing the missing jobs
+	if scenario != nil && scenario.Status == models.EnrichmentStatusFailed {
+		enrichment, err := w.db.GetEnrichment(enrichmentID)
+		if err != nil {
+			log.Printf("Error checking enrichment %s status: %v", enrichmentID, err)
+			return
+		}
+		if enrichment != nil && !enrichment.Status.IsTerminal() {
+			log.Printf("Scenario %s fails enrichment %s", scenario.Name, enrichmentID)
+			if err := w.db.UpdateEnrichmentStatus(enrichmentID, models.EnrichmentStatusFailed, enrichment.Result); err != nil {
+				log.Printf("Error marking enrichment %s as failed: %v", enrichmentID, err)
+				return
+			}
+			w.finish(enrichmentID, userID, models.EnrichmentStatusFailed)
+		}
+		return
+	}
+
 	// Final check: ensure all requested jobs have values (set to empty string if not found)
 	_, completedJobs, err := w.db.GetEnrichmentJobs(enrichmentID)
 	if err != nil {
@@ -220,8 +384,11 @@ func (w *Worker) processEnrichmentThroughProviders(enrichmentID, userID string)
 				log.Printf("Error setting empty phone for enrichment %s: %v", enrichmentID, err)
 			}
 			// Mark as completed (even though empty)
-			if err := w.db.AddCompletedJob(enrichmentID, "phone"); err != nil {
+			completed, err := w.db.AddCompletedJob(enrichmentID, "phone")
+			if err != nil {
 				log.Printf("Error marking phone as completed for enrichment %s: %v", enrichmentID, err)
+			} else {
+				w.publishJobCompleted(enrichmentID, userID, "phone", "", completed)
 			}
 		}
 	}
@@ -241,8 +408,11 @@ func (w *Worker) processEnrichmentThroughProviders(enrichmentID, userID string)
 				log.Printf("Error setting empty email for enrichment %s: %v", enrichmentID, err)
 			}
 			// Mark as completed (even though empty)
-			if err := w.db.AddCompletedJob(enrichmentID, "email"); err != nil {
+			completed, err := w.db.AddCompletedJob(enrichmentID, "email")
+			if err != nil {
 				log.Printf("Error marking email as completed for enrichment %s: %v", enrichmentID, err)
+			} else {
+				w.publishJobCompleted(enrichmentID, userID, "email", "", completed)
 			}
 		}
 	}
@@ -261,6 +431,152 @@ func (w *Worker) processEnrichmentThroughProviders(enrichmentID, userID string)
 	}
 }
 
+// failEnrichment marks an enrichment as failed for the given reason and publishes the status change
+func (w *Worker) failEnrichment(enrichmentID, userID, reason string) {
+	if err := w.db.FailEnrichment(enrichmentID, reason); err != nil {
+		log.Printf("Error marking enrichment %s as failed: %v", enrichmentID, err)
+		return
+	}
+	w.finish(enrichmentID, userID, models.EnrichmentStatusFailed)
+}
+
+// finish announces that an enrichment reached a final status, publishing the status change
+// and delivering the enrichment to its callback URL
+func (w *Worker) finish(enrichmentID, userID string, status models.EnrichmentStatus) {
+	w.publishStatus(enrichmentID, userID, status)
+	w.webhooks.Dispatch(enrichmentID)
+}
+
+// publishStatus publishes a status change of an enrichment
+func (w *Worker) publishStatus(enrichmentID, userID string, status models.EnrichmentStatus) {
+	w.events.Publish(events.Event{
+		Type:         events.TypeStatusChanged,
+		EnrichmentID: enrichmentID,
+		UserID:       userID,
+		Status:       status,
+	})
+}
+
+// publishJobCompleted publishes the completion of a job, and finishes the enrichment
+// when it was the last job and the enrichment is now completed
+func (w *Worker) publishJobCompleted(enrichmentID, userID, jobType, value string, enrichmentCompleted bool) {
+	w.events.Publish(events.Event{
+		Type:         events.TypeJobCompleted,
+		EnrichmentID: enrichmentID,
+		UserID:       userID,
+		Job:          jobType,
+		Value:        value,
+	})
+	if enrichmentCompleted {
+		w.finish(enrichmentID, userID, models.EnrichmentStatusCompleted)
+	}
+}
+
+// orderedProviders returns the providers with the given IDs, in that order.
+// Without IDs, all providers are returned in their default order.
+func (w *Worker) orderedProviders(ids []string) []provider.Provider {
+	if len(ids) == 0 {
+		return w.providers
+	}
+
+	byID := make(map[string]provider.Provider, len(w.providers))
+	for _, p := range w.providers {
+		byID[p.Config().ID] = p
+	}
+
+	ordered := make([]provider.Provider, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			ordered = append(ordered, p)
+		} else {
+			log.Printf("Provider %s is no longer available, skipping it", id)
+		}
+	}
+	return ordered
+}
+
+// scenarioProviders replaces the providers of a job with scripted ones answering with the scenario's steps:
+// the n-th provider supporting the job answers with the n-th step, the others miss immediately
+func (w *Worker) scenarioProviders(providers []provider.Provider, contact models.Contact, job models.JobType, steps []models.ScenarioStep) []provider.Provider {
+	// Found steps without a value find the contact's mock enrichment data
+	phone, email, _ := w.mockData.GetEnrichmentData(contact.ID)
+	mockValue := phone
+	if job == models.JobTypeEmail {
+		mockValue = email
+	}
+
+	scripted := make([]provider.Provider, 0, len(providers))
+	for _, p := range providers {
+		config := p.Config()
+		s := provider.NewScripted(config)
+		if config.Supports(job) && len(steps) > 0 {
+			step := steps[0]
+			steps = steps[1:]
+
+			answer := provider.Step{Delay: time.Duration(step.DelayMs) * time.Millisecond}
+			switch step.Outcome {
+			case models.AttemptOutcomeFound:
+				answer.Found = true
+				answer.Value = step.Value
+				if answer.Value == "" {
+					answer.Value = mockValue
+				}
+			case models.AttemptOutcomeError:
+				message := step.Error
+				if message == "" {
+					message = "scenario error"
+				}
+				answer.Err = errors.New(message)
+			}
+			s.On(job, answer)
+		}
+		scripted = append(scripted, s)
+	}
+	return scripted
+}
+
+// enrichmentSeed returns the seed stored with an enrichment, or draws one if it has none
+func (w *Worker) enrichmentSeed(enrichmentID string) int64 {
+	enrichment, err := w.db.GetEnrichment(enrichmentID)
+	if err != nil {
+		log.Printf("Error getting seed for enrichment %s: %v", enrichmentID, err)
+	}
+	if enrichment != nil && enrichment.Seed != nil {
+		return *enrichment.Seed
+	}
+	return w.config.Rand.Seed()
+}
+
+// finishAttempt records the outcome of a provider attempt started with StartProviderAttempt
+func (w *Worker) finishAttempt(attemptID string, outcome models.AttemptOutcome, attemptError string) {
+	if attemptID == "" {
+		return
+	}
+	if err := w.db.FinishProviderAttempt(attemptID, outcome, attemptError); err != nil {
+		log.Printf("Error recording outcome of provider attempt %s: %v", attemptID, err)
+	}
+}
+
+// cancelRunning interrupts the provider lookups of an enrichment, if it is being processed
+func (w *Worker) cancelRunning(enrichmentID string) {
+	w.mu.Lock()
+	cancel, ok := w.running[enrichmentID]
+	w.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// isStopped checks whether an enrichment has been cancelled, or failed from outside the worker, e.g. by deleting its contact
+func (w *Worker) isStopped(enrichmentID string) bool {
+	enrichment, err := w.db.GetEnrichment(enrichmentID)
+	if err != nil {
+		log.Printf("Error checking enrichment %s status: %v", enrichmentID, err)
+		return false
+	}
+	return enrichment != nil && (enrichment.Status == models.EnrichmentStatusCancelled || enrichment.Status == models.EnrichmentStatusFailed)
+}
+
 // contactInfoMatches checks if the provided contact info matches the third-party data
 func (w *Worker) contactInfoMatches(contactInfo *models.EnrichmentContactInfo, thirdPartyInfo *models.ThirdPartyInfo) bool {
 	// Compare all fields (case-insensitive for strings, order-independent for slices)
@@ -330,11 +646,20 @@ func (w *Worker) stringSlicesEqual(a, b []string) bool {
 
 // processJobForEnrichment processes a single job type (phone or email) through providers
 // for a given enrichment. Runs independently and can complete while other jobs continue.
-func (w *Worker) processJobForEnrichment(enrichmentID string, contact models.Contact, jobType string, providers []models.Provider, successRate float32) {
+// Every random decision of the providers is drawn from rand.
+func (w *Worker) processJobForEnrichment(ctx context.Context, enrichmentID string, contact models.Contact, jobType string, providers []provider.Provider, contactInfoMatches bool, rand *rng.Source) {
 	log.Printf("Starting %s job processing for enrichment %s", jobType, enrichmentID)
 
 	// Process through each provider
-	for _, provider := range providers {
+	for _, p := range providers {
+		config := p.Config()
+		info := config.Provider
+
+		// Skip providers that cannot look up this job type
+		if !config.Supports(models.JobType(jobType)) {
+			continue
+		}
+
 		// Check if enrichment was already completed or failed
 		enrichment, err := w.db.GetEnrichment(enrichmentID)
 		if err != nil {
@@ -356,6 +681,10 @@ func (w *Worker) processJobForEnrichment(enrichmentID string, contact models.Con
 				log.Printf("Enrichment %s is failed, stopping %s job processing", enrichmentID, jobType)
 				return
 			}
+			if enrichment.Status == models.EnrichmentStatusCancelled {
+				log.Printf("Enrichment %s is cancelled, stopping %s job processing", enrichmentID, jobType)
+				return
+			}
 		}
 
 		// Check if this job is already completed
@@ -378,90 +707,101 @@ func (w *Worker) processJobForEnrichment(enrichmentID string, contact models.Con
 			return
 		}
 
-		log.Printf("Checking provider %s for %s job in enrichment %s", provider.Name, jobType, enrichmentID)
+		log.Printf("Checking provider %s for %s job in enrichment %s", info.Name, jobType, enrichmentID)
 
 		// Update the current provider being processed for this specific job type
-		if err := w.db.UpdateEnrichmentStatusWithJobProvider(enrichmentID, models.EnrichmentStatusInProgress, nil, &provider.ID, jobType); err != nil {
+		if err := w.db.UpdateEnrichmentStatusWithJobProvider(enrichmentID, models.EnrichmentStatusInProgress, nil, &info.ID, jobType); err != nil {
 			log.Printf("Error updating current provider for enrichment %s: %v", enrichmentID, err)
+		} else {
+			w.events.Publish(events.Event{
+				Type:         events.TypeProviderChanged,
+				EnrichmentID: enrichmentID,
+				UserID:       contact.ID,
+				Job:          jobType,
+				Provider:     &info,
+			})
 		}
 
-		// Wait 5 seconds ± 1 second (4-6 seconds)
-		delay := 4*time.Second + time.Duration(rand.Intn(2001))*time.Millisecond
-		time.Sleep(delay)
-
-		// Check if this provider finds the requested data
-		found := false
-		if rand.Float32() < successRate {
-			found = true
-			var value string
-			// Get enrichment data (phone/email that can be found)
-			enrichmentPhone, enrichmentEmail, hasData := w.mockData.GetEnrichmentData(contact.ID)
-
-			if jobType == "phone" {
-				if hasData && enrichmentPhone != "" {
-					value = enrichmentPhone
-					log.Printf("Provider %s found phone number for enrichment %s", provider.Name, enrichmentID)
-				} else {
-					log.Printf("Provider %s would have found phone but no phone data available", provider.Name)
-					found = false
-				}
-			} else if jobType == "email" {
-				if hasData && enrichmentEmail != "" {
-					value = enrichmentEmail
-					log.Printf("Provider %s found email for enrichment %s", provider.Name, enrichmentID)
-				} else {
-					log.Printf("Provider %s would have found email but no email data available", provider.Name)
-					found = false
-				}
-			}
-
-			if found && value != "" {
-				// Update only this job type's field in the result (preserves the other field)
-				if err := w.db.UpdateEnrichmentResultField(enrichmentID, jobType, value); err != nil {
-					log.Printf("Error updating %s result for enrichment %s: %v", jobType, enrichmentID, err)
-					continue
-				}
+		// Record the attempt so clients can see which providers were tried
+		attemptID, err := w.db.StartProviderAttempt(enrichmentID, jobType, info.ID)
+		if err != nil {
+			log.Printf("Error recording provider attempt for enrichment %s: %v", enrichmentID, err)
+		}
 
-				// Update the provider ID for this job type
-				if err := w.db.UpdateEnrichmentStatusWithJobProvider(enrichmentID, models.EnrichmentStatusInProgress, nil, &provider.ID, jobType); err != nil {
-					log.Printf("Error updating provider for enrichment %s: %v", enrichmentID, err)
-					continue
-				}
+		result, err := p.Lookup(ctx, provider.Request{
+			EnrichmentID:       enrichmentID,
+			Contact:            contact,
+			Job:                models.JobType(jobType),
+			ContactInfoMatches: contactInfoMatches,
+			Rand:               rand,
+			Clock:              w.config.Clock,
+		})
+
+		// Drop the provider's answer if the enrichment was cancelled or failed while waiting for it
+		if ctx.Err() != nil || w.isStopped(enrichmentID) {
+			w.finishAttempt(attemptID, models.AttemptOutcomeCancelled, "")
+			log.Printf("Enrichment %s was stopped while checking provider %s, stopping %s job processing", enrichmentID, info.Name, jobType)
+			return
+		}
+		if err != nil {
+			w.finishAttempt(attemptID, models.AttemptOutcomeError, err.Error())
+			log.Printf("Provider %s failed to look up %s for enrichment %s: %v, continuing...", info.Name, jobType, enrichmentID, err)
+			continue
+		}
 
-				// Update contact with found value
-				if jobType == "phone" {
-					if err := w.mockData.UpdateContactPhone(contact.ID, value); err != nil {
-						log.Printf("Error updating contact phone for enrichment %s: %v", enrichmentID, err)
-					} else {
-						log.Printf("Updated contact %s with phone number: %s", contact.ID, value)
-					}
-				} else if jobType == "email" {
-					if err := w.mockData.UpdateContactEmail(contact.ID, value); err != nil {
-						log.Printf("Error updating contact email for enrichment %s: %v", enrichmentID, err)
-					} else {
-						log.Printf("Updated contact %s with email: %s", contact.ID, value)
-					}
-				}
+		// Check if this provider found the requested data
+		if !result.Found {
+			w.finishAttempt(attemptID, models.AttemptOutcomeNotFound, "")
+			log.Printf("Provider %s did not find %s for enrichment %s, continuing...", info.Name, jobType, enrichmentID)
+			continue
+		}
+		log.Printf("Provider %s found %s for enrichment %s", info.Name, jobType, enrichmentID)
 
-			// Mark job as completed (after result and contact are updated)
-			// This will read the latest result from DB, so it should have our value
-			if err := w.db.AddCompletedJob(enrichmentID, jobType); err != nil {
-				log.Printf("Error marking %s as completed for enrichment %s: %v", jobType, enrichmentID, err)
-				continue
-			}
+		// Update only this job type's field in the result (preserves the other field), and the contact with it
+		// A value that can't be normalized or stored counts as a provider error, so the attempt is only
+		// recorded as found once the value is stored
+		value, contactUpdated, err := w.db.RecordFoundValue(enrichmentID, contact.ID, jobType, result.Value)
+		if err != nil {
+			w.finishAttempt(attemptID, models.AttemptOutcomeError, err.Error())
+			log.Printf("Error updating %s result for enrichment %s: %v", jobType, enrichmentID, err)
+			continue
+		}
+		w.finishAttempt(attemptID, models.AttemptOutcomeFound, "")
+		if contactUpdated {
+			log.Printf("Updated contact %s with %s: %s", contact.ID, jobType, value)
+		}
+		w.events.Publish(events.Event{
+			Type:         events.TypeValueFound,
+			EnrichmentID: enrichmentID,
+			UserID:       contact.ID,
+			Job:          jobType,
+			Provider:     &info,
+			Value:        value,
+		})
+
+		// Update the provider ID for this job type
+		if err := w.db.UpdateEnrichmentStatusWithJobProvider(enrichmentID, models.EnrichmentStatusInProgress, nil, &info.ID, jobType); err != nil {
+			log.Printf("Error updating provider for enrichment %s: %v", enrichmentID, err)
+			continue
+		}
 
-			// Clear provider ID since job is completed (result is already saved)
-			// Use ClearJobProvider to avoid overwriting the status set by AddCompletedJob
-			if err := w.db.ClearJobProvider(enrichmentID, jobType); err != nil {
-				log.Printf("Error clearing provider for completed %s job in enrichment %s: %v", jobType, enrichmentID, err)
-			}
+		// Mark job as completed (after result and contact are updated)
+		// This will read the latest result from DB, so it should have our value
+		completed, err := w.db.AddCompletedJob(enrichmentID, jobType)
+		if err != nil {
+			log.Printf("Error marking %s as completed for enrichment %s: %v", jobType, enrichmentID, err)
+			continue
+		}
 
-				log.Printf("%s job completed for enrichment %s by provider %s", jobType, enrichmentID, provider.Name)
-				return // Job found, stop processing this job type
-			}
-		} else {
-			log.Printf("Provider %s did not find %s for enrichment %s, continuing...", provider.Name, jobType, enrichmentID)
+		// Clear provider ID since job is completed (result is already saved)
+		// Use ClearJobProvider to avoid overwriting the status set by AddCompletedJob
+		if err := w.db.ClearJobProvider(enrichmentID, jobType); err != nil {
+			log.Printf("Error clearing provider for completed %s job in enrichment %s: %v", jobType, enrichmentID, err)
 		}
+		w.publishJobCompleted(enrichmentID, contact.ID, jobType, value, completed)
+
+		log.Printf("%s job completed for enrichment %s by provider %s", jobType, enrichmentID, info.Name)
+		return // Job found, stop processing this job type
 	}
 
 	// If we've checked all providers and didn't find the value, the job will be marked as completed
@@ -0,0 +1,279 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/surfe/mock-api/internal/clock"
+	"github.com/surfe/mock-api/internal/data"
+	"github.com/surfe/mock-api/internal/database"
+	"github.com/surfe/mock-api/internal/events"
+	"github.com/surfe/mock-api/internal/models"
+	"github.com/surfe/mock-api/internal/provider"
+	"github.com/surfe/mock-api/internal/rng"
+	"github.com/surfe/mock-api/internal/webhook"
+)
+
+// testWorker is a worker on its own database, with a paused virtual clock and an in_progress phone enrichment
+type testWorker struct {
+	*Worker
+	db           *database.DB
+	clock        *clock.Virtual
+	contact      models.Contact
+	enrichmentID string
+}
+
+// newTestWorker creates a test worker that looks up phones through the given providers
+func newTestWorker(t *testing.T, providers ...provider.Provider) *testWorker {
+	t.Helper()
+
+	clk := clock.NewVirtual(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), 0)
+	db, err := database.New(filepath.Join(t.TempDir(), "worker.db"), clk)
+	if err != nil {
+		t.Fatalf("database.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	contact, err := db.CreateContact(models.Contact{ID: "contact-1", FirstName: "Jane", LastName: "Doe"})
+	if err != nil {
+		t.Fatalf("CreateContact: %v", err)
+	}
+	enrichment, err := db.CreateEnrichmentWithOptions(database.EnrichmentOptions{UserID: contact.ID, Jobs: []string{"phone"}})
+	if err != nil {
+		t.Fatalf("CreateEnrichmentWithOptions: %v", err)
+	}
+	if err := db.UpdateEnrichmentStatus(enrichment.ID, models.EnrichmentStatusInProgress, nil); err != nil {
+		t.Fatalf("UpdateEnrichmentStatus: %v", err)
+	}
+
+	mockData := data.NewMockData()
+	config := DefaultConfig()
+	config.Clock = clk
+	config.Rand = rng.New(1)
+	w := New(db, mockData, events.NewBroker(events.DefaultHistorySize, clk), webhook.New(db, mockData, webhook.DefaultConfig()), providers, config)
+
+	return &testWorker{Worker: w, db: db, clock: clk, contact: *contact, enrichmentID: enrichment.ID}
+}
+
+// processPhone runs the phone job of the test enrichment through the worker's providers
+func (tw *testWorker) processPhone(ctx context.Context) {
+	tw.processJobForEnrichment(ctx, tw.enrichmentID, tw.contact, "phone", tw.providers, false, rng.New(1))
+}
+
+// outcomes returns the outcome of each provider's attempt, keyed by provider ID
+func (tw *testWorker) outcomes(t *testing.T) map[string]models.ProviderAttempt {
+	t.Helper()
+
+	attempts, err := tw.db.GetProviderAttempts(tw.enrichmentID)
+	if err != nil {
+		t.Fatalf("GetProviderAttempts: %v", err)
+	}
+	byProvider := make(map[string]models.ProviderAttempt, len(attempts))
+	for _, a := range attempts {
+		if _, ok := byProvider[a.ProviderID]; ok {
+			t.Fatalf("provider %s was attempted more than once", a.ProviderID)
+		}
+		byProvider[a.ProviderID] = a
+	}
+	return byProvider
+}
+
+// enrichment returns the test enrichment as it is stored
+func (tw *testWorker) enrichment(t *testing.T) *models.Enrichment {
+	t.Helper()
+
+	enrichment, err := tw.db.GetEnrichment(tw.enrichmentID)
+	if err != nil || enrichment == nil {
+		t.Fatalf("GetEnrichment: %v, %v", enrichment, err)
+	}
+	return enrichment
+}
+
+// scriptedPhoneProvider creates a scripted provider that looks up phones
+func scriptedPhoneProvider(id string, steps ...provider.Step) *provider.Scripted {
+	config := models.ProviderConfig{
+		Provider: models.Provider{ID: id, Name: id},
+		Jobs:     []models.JobType{models.JobTypePhone},
+	}
+	return provider.NewScripted(config).On(models.JobTypePhone, steps...)
+}
+
+func TestProcessJobForEnrichment(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    provider.Step
+		second   provider.Step
+		outcomes map[string]models.AttemptOutcome
+		errors   map[string]string
+		phone    string // the stored phone, empty if the job was not completed
+		e164     string
+	}{
+		{
+			name:     "found by the first provider",
+			first:    provider.Step{Found: true, Value: "+1 (555) 010-0001"},
+			outcomes: map[string]models.AttemptOutcome{"first": models.AttemptOutcomeFound},
+			phone:    "+1 (555) 010-0001",
+			e164:     "+15550100001",
+		},
+		{
+			name:     "not found moves on to the next provider",
+			first:    provider.Step{},
+			second:   provider.Step{Found: true, Value: "555-010-0002"},
+			outcomes: map[string]models.AttemptOutcome{"first": models.AttemptOutcomeNotFound, "second": models.AttemptOutcomeFound},
+			phone:    "555-010-0002",
+			e164:     "+15550100002",
+		},
+		{
+			name:     "found without a value counts as not found",
+			first:    provider.Step{Found: true},
+			outcomes: map[string]models.AttemptOutcome{"first": models.AttemptOutcomeNotFound, "second": models.AttemptOutcomeNotFound},
+		},
+		{
+			name:     "not found by any provider",
+			outcomes: map[string]models.AttemptOutcome{"first": models.AttemptOutcomeNotFound, "second": models.AttemptOutcomeNotFound},
+		},
+		{
+			name:     "provider error moves on to the next provider",
+			first:    provider.Step{Err: errors.New("rate limited")},
+			second:   provider.Step{Found: true, Value: "+44 20 7946 0003"},
+			outcomes: map[string]models.AttemptOutcome{"first": models.AttemptOutcomeError, "second": models.AttemptOutcomeFound},
+			errors:   map[string]string{"first": "rate limited"},
+			phone:    "+44 20 7946 0003",
+			e164:     "+442079460003",
+		},
+		{
+			name:     "a value that cannot be stored is an error",
+			first:    provider.Step{Found: true, Value: "call me maybe"},
+			second:   provider.Step{Found: true, Value: "+15550100004"},
+			outcomes: map[string]models.AttemptOutcome{"first": models.AttemptOutcomeError, "second": models.AttemptOutcomeFound},
+			phone:    "+15550100004",
+			e164:     "+15550100004",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tw := newTestWorker(t, scriptedPhoneProvider("first", tt.first), scriptedPhoneProvider("second", tt.second))
+			tw.processPhone(context.Background())
+
+			attempts := tw.outcomes(t)
+			if len(attempts) != len(tt.outcomes) {
+				t.Errorf("got %d attempts, want %d: %+v", len(attempts), len(tt.outcomes), attempts)
+			}
+			for id, want := range tt.outcomes {
+				if got := attempts[id].Outcome; got != want {
+					t.Errorf("provider %s outcome = %q, want %q", id, got, want)
+				}
+				if attempts[id].FinishedAt == "" {
+					t.Errorf("provider %s attempt was not finished", id)
+				}
+			}
+			for id, want := range tt.errors {
+				if got := attempts[id].Error; got != want {
+					t.Errorf("provider %s error = %q, want %q", id, got, want)
+				}
+			}
+
+			enrichment := tw.enrichment(t)
+			if tt.phone == "" {
+				if enrichment.Status != models.EnrichmentStatusInProgress {
+					t.Errorf("status = %q, want %q", enrichment.Status, models.EnrichmentStatusInProgress)
+				}
+				if enrichment.Result != nil && enrichment.Result.Phone != "" {
+					t.Errorf("phone = %q, want none", enrichment.Result.Phone)
+				}
+				return
+			}
+
+			if enrichment.Status != models.EnrichmentStatusCompleted {
+				t.Errorf("status = %q, want %q", enrichment.Status, models.EnrichmentStatusCompleted)
+			}
+			if enrichment.Result == nil || enrichment.Result.Phone != tt.phone || enrichment.Result.PhoneE164 != tt.e164 {
+				t.Errorf("result = %+v, want phone %q and phoneE164 %q", enrichment.Result, tt.phone, tt.e164)
+			}
+
+			contact, err := tw.db.GetContact(tw.contact.ID)
+			if err != nil {
+				t.Fatalf("GetContact: %v", err)
+			}
+			if contact.Phone != tt.phone || contact.PhoneE164 != tt.e164 || contact.Version != 2 {
+				t.Errorf("contact = %+v, want phone %q, phoneE164 %q and version 2", contact, tt.phone, tt.e164)
+			}
+		})
+	}
+}
+
+func TestProcessJobForEnrichmentStoppedDuringLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(tw *testWorker, cancel context.CancelFunc)
+	}{
+		{
+			name: "context cancelled",
+			stop: func(tw *testWorker, cancel context.CancelFunc) {
+				cancel()
+			},
+		},
+		{
+			name: "enrichment cancelled before the provider answers",
+			stop: func(tw *testWorker, cancel context.CancelFunc) {
+				if _, err := tw.db.CancelEnrichment(tw.enrichmentID); err != nil {
+					t.Errorf("CancelEnrichment: %v", err)
+				}
+				// Let the provider answer; its value must be dropped
+				tw.clock.Advance(time.Hour)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := scriptedPhoneProvider("first", provider.Step{Delay: time.Hour, Found: true, Value: "+15550100005"})
+			second := scriptedPhoneProvider("second", provider.Step{Found: true, Value: "+15550100006"})
+			tw := newTestWorker(t, first, second)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				tw.processPhone(ctx)
+			}()
+
+			// Wait for the first lookup to be in flight
+			deadline := time.Now().Add(5 * time.Second)
+			for len(tw.clock.Pending()) == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("the first provider was never called")
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			tt.stop(tw, cancel)
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the job did not stop")
+			}
+
+			attempts := tw.outcomes(t)
+			if got := attempts["first"].Outcome; got != models.AttemptOutcomeCancelled {
+				t.Errorf("first provider outcome = %q, want %q", got, models.AttemptOutcomeCancelled)
+			}
+			if calls := second.Calls(); len(calls) != 0 {
+				t.Errorf("second provider got %d calls, want none", len(calls))
+			}
+
+			enrichment := tw.enrichment(t)
+			if enrichment.Result != nil && enrichment.Result.Phone != "" {
+				t.Errorf("phone = %q, want none", enrichment.Result.Phone)
+			}
+			if contact, _ := tw.db.GetContact(tw.contact.ID); contact.Phone != "" || contact.Version != 1 {
+				t.Errorf("contact = %+v, want it untouched", contact)
+			}
+		})
+	}
+}